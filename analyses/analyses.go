@@ -0,0 +1,73 @@
+// Package analyses provides the textbook data-flow analyses on top of the dataflowanalysis solvers.
+//
+// The analyses only need to know which variables each node defines and uses (and, for the expression analyses,
+// which expressions it evaluates), so any language can use them by implementing DefUse or ExprDefUse.
+package analyses
+
+import dfa "github.com/skius/dataflowanalysis"
+
+// DefUse describes the variables each node of a graph defines and uses
+type DefUse interface {
+	Defs(label int) []string // Variables assigned by the node
+	Uses(label int) []string // Variables read by the node
+}
+
+// ExprDefUse additionally describes the expressions each node evaluates
+type ExprDefUse interface {
+	DefUse
+	Exprs(label int) []Expr // Non-trivial expressions evaluated by the node
+}
+
+// An Expr is an expression evaluated by a node
+type Expr struct {
+	Text string   // Canonical text of the expression, two Exprs with the same Text are the same expression
+	Vars []string // Variables read by the expression
+}
+
+// A Problem bundles the lattice and transfer function of a path-insensitive data-flow analysis
+type Problem struct {
+	Backward bool
+	Merge    func(dfa.Fact, dfa.Fact) dfa.Fact
+	Flow     func(dfa.Fact, dfa.NodePI) dfa.Fact
+	Initial  dfa.Fact
+	Boundary dfa.Fact // The fact flowing into the entries, or out of the exits if Backward
+}
+
+// Solve runs the Problem with the solver options, boundaryIds are the entries of a forward or the exits of a backward
+// Problem
+func (p *Problem) Solve(
+	boundaryIds []int,
+	ids []int,
+	idToNode map[int]dfa.NodePI,
+	opts ...dfa.Option,
+) (in, out map[int]dfa.Fact) {
+	if p.Backward {
+		return dfa.RunBackwardPIFrom(boundaryIds, ids, idToNode, p.Merge, p.Flow, p.Initial, p.Boundary, opts...)
+	}
+	return dfa.RunForwardPI(boundaryIds, ids, idToNode, p.Merge, p.Flow, p.Initial, p.Boundary, opts...)
+}
+
+// allVars returns every variable defined or used in the graph
+func allVars(ids []int, du DefUse) VarSet {
+	vars := make(VarSet)
+	for _, id := range ids {
+		for _, v := range du.Defs(id) {
+			vars[v] = struct{}{}
+		}
+		for _, v := range du.Uses(id) {
+			vars[v] = struct{}{}
+		}
+	}
+	return vars
+}
+
+// allExprs returns every expression evaluated in the graph
+func allExprs(ids []int, eu ExprDefUse) ExprSet {
+	exprs := make(ExprSet)
+	for _, id := range ids {
+		for _, e := range eu.Exprs(id) {
+			exprs[e.Text] = e
+		}
+	}
+	return exprs
+}
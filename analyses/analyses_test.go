@@ -0,0 +1,109 @@
+package analyses_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/analyses"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+/*
+	The statements of the test graphs form a tiny language:
+		x = 1 + y     An assignment, defining x and using the variables on the right
+		x == 1        A branch, using x
+		print x       A use
+*/
+
+// The test graphs, a diamond joining at 5 and a loop with the head 2
+const (
+	diamond = `entry 1; 1 -> 2; 2 -T-> 3 -> 5; 2 -> 4 -> 5
+		1 "x = 1"; 2 "x == 1"; 3 "y = 2"; 4 "y = x"; 5 "print y"`
+	loop = `entry 1; 1 -> 2 -> 3 -> 2; 2 -T-> 4
+		1 "i = 0"; 2 "i == 9"; 3 "i = i + 1"; 4 "print i + s"`
+)
+
+// defUse implements analyses.DefUse for graphs of the tiny language
+type defUse struct {
+	g *dfa.Graph
+}
+
+func (du defUse) Defs(label int) []string {
+	s, _ := du.g.Node(label).Get().(string)
+	if toks := strings.Fields(s); len(toks) >= 2 && toks[1] == "=" {
+		return []string{toks[0]}
+	}
+	return []string{}
+}
+
+func (du defUse) Uses(label int) []string {
+	s, _ := du.g.Node(label).Get().(string)
+	toks := strings.Fields(s)
+	if len(toks) >= 2 && toks[1] == "=" {
+		toks = toks[2:]
+	}
+	uses := make([]string, 0)
+	for _, tok := range toks {
+		if unicode.IsLetter(rune(tok[0])) && tok != "print" {
+			uses = append(uses, tok)
+		}
+	}
+	return uses
+}
+
+func def(v string, label int) analyses.Definition {
+	return analyses.Definition{Var: v, Label: label}
+}
+
+func TestReachingDefinitions(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph string
+		label int
+		want  []analyses.Definition // The sorted Definitions reaching the node
+	}{
+		{
+			// Both assignments of y reach the join, and the uninitialized y does not
+			name:  "diamond",
+			graph: diamond,
+			label: 5,
+			want:  []analyses.Definition{def("x", 1), def("y", 3), def("y", 4)},
+		},
+		{
+			name:  "diamond branch",
+			graph: diamond,
+			label: 4,
+			want:  []analyses.Definition{def("x", 1), def("y", analyses.Uninitialized)},
+		},
+		{
+			// The assignment in the body reaches the head around the back edge, s is never assigned
+			name:  "loop head",
+			graph: loop,
+			label: 2,
+			want:  []analyses.Definition{def("i", 1), def("i", 3), def("s", analyses.Uninitialized)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := dfa.MustParseGraph(tt.graph)
+			res := analyses.ReachingDefinitions(g.Entries, g.IDs(), g.NodesPI(), defUse{g})
+			if got := res.In[tt.label].Sorted(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("in(%d) = %v, want %v", tt.label, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLiveVariables(t *testing.T) {
+	// i is live around the loop, s from the entry to its use after the loop
+	g := dfa.MustParseGraph(loop)
+	res := analyses.LiveVariables(g.IDs(), g.NodesPI(), defUse{g})
+	want := map[int][]string{1: {"s"}, 2: {"i", "s"}, 3: {"i", "s"}, 4: {"i", "s"}}
+	for id, vars := range want {
+		if got := res.In[id].Sorted(); !reflect.DeepEqual(got, vars) {
+			t.Errorf("in(%d) = %v, want %v", id, got, vars)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package analyses
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"sort"
+	"strconv"
+)
+
+// A Use is the read of Var at the node with label Label
+type Use struct {
+	Var   string
+	Label int
+}
+
+func (u Use) String() string {
+	return "(" + u.Var + ", " + strconv.Itoa(u.Label) + ")"
+}
+
+// DefUseChains links every Definition to the Uses it may reach, and every Use to the Definitions that may reach it
+type DefUseChains struct {
+	DefsAt   map[int][]Definition // The Definitions made by each node
+	UsesAt   map[int][]Use        // The Uses made by each node
+	UsesOf   map[Definition][]Use // The Uses each Definition may reach, sorted
+	DefsOf   map[Use][]Definition // The Definitions that may reach each Use, sorted
+	renaming *renaming
+}
+
+// BuildDefUseChains computes the def-use chains of the graph, which are those of ReachingDefinitions, without solving
+// reaching definitions for every node: the definitions of each used variable are renamed in a walk of the dominator
+// tree, with phi functions merging them at their iterated dominance frontiers, so the work is proportional to the
+// numbers of definitions, uses and phis instead of nodes times variables.
+// Uses that may read an unassigned variable are linked to its Uninitialized pseudo-definition.
+func BuildDefUseChains(entryIds []int, ids []int, idToNode map[int]dfa.NodePI, du DefUse) *DefUseChains {
+	c := &DefUseChains{
+		DefsAt: make(map[int][]Definition, len(ids)),
+		UsesAt: make(map[int][]Use, len(ids)),
+		UsesOf: make(map[Definition][]Use),
+		DefsOf: make(map[Use][]Definition),
+	}
+	used := make(map[string]bool)
+	for _, id := range ids {
+		for _, v := range du.Defs(id) {
+			c.DefsAt[id] = append(c.DefsAt[id], Definition{Var: v, Label: id})
+		}
+		for _, v := range du.Uses(id) {
+			c.UsesAt[id] = append(c.UsesAt[id], Use{Var: v, Label: id})
+			used[v] = true
+		}
+	}
+
+	r := newRenaming(entryIds, ids, idToNode)
+	c.renaming = r
+	r.vars = allVars(ids, du)
+
+	// Phi placement, only used variables need versions
+	for _, id := range ids {
+		for _, d := range c.DefsAt[id] {
+			r.defSites[d.Var] = append(r.defSites[d.Var], id)
+		}
+	}
+	vars := make([]string, 0, len(used))
+	for v := range used {
+		vars = append(vars, v)
+	}
+	sort.Strings(vars)
+	for _, v := range vars {
+		for _, id := range r.iteratedFrontier(append(r.defSites[v], r.roots...)) {
+			r.phis[id] = append(r.phis[id], &defPhi{v: v})
+		}
+	}
+
+	// Renaming, stacks[v] holds the values of v assigned on the path from the root to the current node
+	values := make(map[Use]reachingValue)
+	stacks := make(map[string][]reachingValue)
+	pushed := make(map[int][]string)
+	fromEntry := false
+	top := func(v string) reachingValue {
+		if st := stacks[v]; len(st) > 0 {
+			return st[len(st)-1]
+		}
+		if fromEntry {
+			return reachingValue{def: Definition{Var: v, Label: Uninitialized}, ok: true}
+		}
+		return reachingValue{}
+	}
+	push := func(id int, v string, val reachingValue) {
+		stacks[v] = append(stacks[v], val)
+		pushed[id] = append(pushed[id], v)
+	}
+
+	r.dom.Visit(func(id int) {
+		if _, ok := r.dom.Idom(id); !ok {
+			fromEntry = r.isEntry[id]
+		}
+		for _, p := range r.phis[id] {
+			if r.isEntry[id] {
+				p.args = append(p.args, reachingValue{def: Definition{Var: p.v, Label: Uninitialized}, ok: true})
+			}
+			push(id, p.v, reachingValue{phi: p, ok: true})
+		}
+		for _, u := range c.UsesAt[id] {
+			values[u] = top(u.Var)
+		}
+		for _, d := range c.DefsAt[id] {
+			if used[d.Var] {
+				push(id, d.Var, reachingValue{def: d, ok: true})
+			}
+		}
+		for _, succ := range idToNode[id].Succs() {
+			for _, p := range r.phis[succ] {
+				p.args = append(p.args, top(p.v))
+			}
+		}
+	}, func(id int) {
+		for _, v := range pushed[id] {
+			stacks[v] = stacks[v][:len(stacks[v])-1]
+		}
+	})
+
+	var t tarjan
+	for _, id := range ids {
+		for _, u := range c.UsesAt[id] {
+			c.DefsOf[u] = t.definitions(values[u])
+			for _, d := range c.DefsOf[u] {
+				c.UsesOf[d] = append(c.UsesOf[d], u)
+			}
+		}
+	}
+
+	for _, uses := range c.UsesOf {
+		sort.Slice(uses, func(i, j int) bool {
+			if uses[i].Label != uses[j].Label {
+				return uses[i].Label < uses[j].Label
+			}
+			return uses[i].Var < uses[j].Var
+		})
+	}
+	return c
+}
+
+// Reaching returns the sorted Definitions of the variable that may reach the node, like the reaching definitions of
+// v in front of it. For a Use this is DefsOf, for other variables the phis of v are placed and resolved on demand.
+func (c *DefUseChains) Reaching(label int, v string) []Definition {
+	if defs, ok := c.DefsOf[Use{Var: v, Label: label}]; ok {
+		return defs
+	}
+	r := c.renaming
+	if !r.vars.Contains(v) || !r.dom.Reachable(label) {
+		return []Definition{}
+	}
+
+	phis := make(map[int]*defPhi)
+	for _, id := range r.iteratedFrontier(append(r.defSites[v], r.roots...)) {
+		phis[id] = nil
+	}
+	var atStart, atEnd func(id int) reachingValue
+	atStart = func(id int) reachingValue {
+		if p, ok := phis[id]; ok {
+			if p == nil {
+				p = &defPhi{v: v}
+				phis[id] = p
+				if r.isEntry[id] {
+					p.args = append(p.args, reachingValue{def: Definition{Var: v, Label: Uninitialized}, ok: true})
+				}
+				for _, pred := range r.idToNode[id].Preds() {
+					if r.dom.Reachable(pred) {
+						p.args = append(p.args, atEnd(pred))
+					}
+				}
+			}
+			return reachingValue{phi: p, ok: true}
+		}
+		if idom, ok := r.dom.Idom(id); ok {
+			return atEnd(idom)
+		}
+		if r.isEntry[id] {
+			return reachingValue{def: Definition{Var: v, Label: Uninitialized}, ok: true}
+		}
+		return reachingValue{}
+	}
+	atEnd = func(id int) reachingValue {
+		for _, d := range c.DefsAt[id] {
+			if d.Var == v {
+				return reachingValue{def: d, ok: true}
+			}
+		}
+		return atStart(id)
+	}
+
+	var t tarjan
+	return t.definitions(atStart(label))
+}
+
+// A reachingValue is what a variable holds at a point of the renaming walk: a Definition, a phi merging the values
+// of the predecessors, or, if ok is false, nothing, on paths from a part of the graph unreachable from the entries
+type reachingValue struct {
+	def Definition
+	phi *defPhi
+	ok  bool
+}
+
+// A defPhi merges the values of v flowing into a node
+type defPhi struct {
+	v    string
+	args []reachingValue
+
+	// Set by tarjan
+	defs           []Definition
+	index, lowlink int
+	onStack        bool
+}
+
+// A renaming holds the dominator tree the chains are built on
+type renaming struct {
+	dom       *dfa.DomTree
+	roots     []int // The entries, then a node of every part unreachable from them
+	isEntry   map[int]bool
+	frontiers map[int][]int
+	vars      VarSet
+	defSites  map[string][]int // The nodes defining each variable
+	phis      map[int][]*defPhi
+	idToNode  map[int]dfa.NodePI
+}
+
+// newRenaming builds the dominator tree of the graph, rooted at the entries and at pseudo-entries which make every
+// node reachable. Like in the dense analysis, no definitions reach the pseudo-entries.
+func newRenaming(entryIds []int, ids []int, idToNode map[int]dfa.NodePI) *renaming {
+	r := &renaming{
+		isEntry:  make(map[int]bool, len(entryIds)),
+		defSites: make(map[string][]int),
+		phis:     make(map[int][]*defPhi),
+		idToNode: idToNode,
+	}
+	visited := make(map[int]bool, len(ids))
+	var visit func(id int)
+	visit = func(id int) {
+		visited[id] = true
+		for _, succ := range idToNode[id].Succs() {
+			if _, ok := idToNode[succ]; ok && !visited[succ] {
+				visit(succ)
+			}
+		}
+	}
+	for _, id := range entryIds {
+		r.isEntry[id] = true
+	}
+	for _, id := range append(append([]int{}, entryIds...), ids...) {
+		if !visited[id] {
+			r.roots = append(r.roots, id)
+			visit(id)
+		}
+	}
+
+	r.dom = dfa.DominatorsPI(r.roots, ids, idToNode)
+	r.frontiers = r.dom.Frontiers()
+	return r
+}
+
+// iteratedFrontier returns the iterated dominance frontier of the nodes, visiting only the frontiers it contains
+func (r *renaming) iteratedFrontier(ids []int) []int {
+	queued := make(map[int]bool, len(ids))
+	inResult := make(map[int]bool)
+	worklist := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !queued[id] {
+			queued[id] = true
+			worklist = append(worklist, id)
+		}
+	}
+
+	result := make([]int, 0)
+	for len(worklist) > 0 {
+		id := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		for _, f := range r.frontiers[id] {
+			if inResult[f] {
+				continue
+			}
+			inResult[f] = true
+			result = append(result, f)
+			if !queued[f] {
+				queued[f] = true
+				worklist = append(worklist, f)
+			}
+		}
+	}
+	return result
+}
+
+// tarjan resolves phis to the Definitions they merge, finding the strongly connected components of phis depending on
+// each other with Tarjan's algorithm, since all phis of a component merge the same Definitions
+type tarjan struct {
+	index int
+	stack []*defPhi
+}
+
+// definitions returns the sorted Definitions the value stands for
+func (t *tarjan) definitions(val reachingValue) []Definition {
+	switch {
+	case !val.ok:
+		return []Definition{}
+	case val.phi == nil:
+		return []Definition{val.def}
+	}
+	if val.phi.index == 0 {
+		t.visit(val.phi)
+	}
+	return val.phi.defs
+}
+
+func (t *tarjan) visit(p *defPhi) {
+	t.index++
+	p.index, p.lowlink = t.index, t.index
+	t.stack = append(t.stack, p)
+	p.onStack = true
+	for _, arg := range p.args {
+		if q := arg.phi; q != nil {
+			if q.index == 0 {
+				t.visit(q)
+				if q.lowlink < p.lowlink {
+					p.lowlink = q.lowlink
+				}
+			} else if q.onStack && q.index < p.lowlink {
+				p.lowlink = q.index
+			}
+		}
+	}
+	if p.lowlink != p.index {
+		return
+	}
+
+	// p is the root of a component, which merges the Definitions of its arguments outside the component
+	component := make([]*defPhi, 0, 1)
+	for {
+		q := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+		q.onStack = false
+		component = append(component, q)
+		if q == p {
+			break
+		}
+	}
+	set := make(DefSet)
+	for _, q := range component {
+		for _, arg := range q.args {
+			switch {
+			case !arg.ok:
+			case arg.phi == nil:
+				set[arg.def] = struct{}{}
+			case !arg.phi.onStack && arg.phi.defs != nil:
+				for _, d := range arg.phi.defs {
+					set[d] = struct{}{}
+				}
+			}
+		}
+	}
+	defs := set.Sorted()
+	for _, q := range component {
+		q.defs = defs
+	}
+}
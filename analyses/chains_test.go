@@ -0,0 +1,139 @@
+package analyses_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/analyses"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDefUseChains(t *testing.T) {
+	tests := []struct {
+		name   string
+		graph  string
+		defsOf map[analyses.Use][]analyses.Definition
+		usesOf map[analyses.Definition][]analyses.Use
+	}{
+		{
+			name:  "diamond",
+			graph: diamond,
+			defsOf: map[analyses.Use][]analyses.Definition{
+				{Var: "x", Label: 2}: {def("x", 1)},
+				{Var: "x", Label: 4}: {def("x", 1)},
+				{Var: "y", Label: 5}: {def("y", 3), def("y", 4)},
+			},
+			usesOf: map[analyses.Definition][]analyses.Use{
+				def("x", 1): {{Var: "x", Label: 2}, {Var: "x", Label: 4}},
+				def("y", 3): {{Var: "y", Label: 5}},
+				def("y", 4): {{Var: "y", Label: 5}},
+			},
+		},
+		{
+			// The phi of i at the head 2 merges the assignments before and in the loop, and s is never assigned
+			name:  "loop",
+			graph: loop,
+			defsOf: map[analyses.Use][]analyses.Definition{
+				{Var: "i", Label: 2}: {def("i", 1), def("i", 3)},
+				{Var: "i", Label: 3}: {def("i", 1), def("i", 3)},
+				{Var: "i", Label: 4}: {def("i", 1), def("i", 3)},
+				{Var: "s", Label: 4}: {def("s", analyses.Uninitialized)},
+			},
+			usesOf: map[analyses.Definition][]analyses.Use{
+				def("i", 1):                      {{Var: "i", Label: 2}, {Var: "i", Label: 3}, {Var: "i", Label: 4}},
+				def("i", 3):                      {{Var: "i", Label: 2}, {Var: "i", Label: 3}, {Var: "i", Label: 4}},
+				def("s", analyses.Uninitialized): {{Var: "s", Label: 4}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := dfa.MustParseGraph(tt.graph)
+			c := analyses.BuildDefUseChains(g.Entries, g.IDs(), g.NodesPI(), defUse{g})
+			if !reflect.DeepEqual(c.DefsOf, tt.defsOf) {
+				t.Errorf("DefsOf = %v, want %v", c.DefsOf, tt.defsOf)
+			}
+			if !reflect.DeepEqual(c.UsesOf, tt.usesOf) {
+				t.Errorf("UsesOf = %v, want %v", c.UsesOf, tt.usesOf)
+			}
+
+			// Reaching agrees with the dense analysis on every variable, used by the node or not
+			dense := analyses.ReachingDefinitions(g.Entries, g.IDs(), g.NodesPI(), defUse{g})
+			for _, id := range g.IDs() {
+				for _, v := range []string{"i", "s", "x", "y"} {
+					if got, want := c.Reaching(id, v), dense.In[id].Of(v); !reflect.DeepEqual(got, want) {
+						t.Errorf("Reaching(%d, %s) = %v, want %v", id, v, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRunSparse(t *testing.T) {
+	// The fact of a Definition is the set of labels of the Definitions its value is computed from, itself included
+	derivedFrom := func(du analyses.DefUse) func(int, map[string]dfa.Fact) map[string]dfa.Fact {
+		return func(label int, operands map[string]dfa.Fact) map[string]dfa.Fact {
+			f := analyses.NewVarSet(strconv.Itoa(label))
+			for _, op := range operands {
+				f = f.Union(op.(analyses.VarSet))
+			}
+			res := make(map[string]dfa.Fact)
+			for _, v := range du.Defs(label) {
+				res[v] = f
+			}
+			return res
+		}
+	}
+	union := func(a, b dfa.Fact) dfa.Fact {
+		return a.(analyses.VarSet).Union(b.(analyses.VarSet))
+	}
+
+	tests := []struct {
+		name  string
+		graph string
+		use   analyses.Use
+		defs  map[analyses.Definition][]string
+		at    []string // The fact at the use
+	}{
+		{
+			name:  "diamond",
+			graph: diamond,
+			use:   analyses.Use{Var: "y", Label: 5},
+			defs:  map[analyses.Definition][]string{def("x", 1): {"1"}, def("y", 3): {"3"}, def("y", 4): {"1", "4"}},
+			at:    []string{"1", "3", "4"},
+		},
+		{
+			// The assignment in the loop reads its own value through the phi at the head
+			name:  "loop",
+			graph: loop,
+			use:   analyses.Use{Var: "i", Label: 4},
+			defs:  map[analyses.Definition][]string{def("i", 1): {"1"}, def("i", 3): {"1", "3"}},
+			at:    []string{"1", "3"},
+		},
+		{
+			name:  "uninitialized",
+			graph: loop,
+			use:   analyses.Use{Var: "s", Label: 4},
+			defs:  map[analyses.Definition][]string{def("s", analyses.Uninitialized): {"?"}},
+			at:    []string{"?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := dfa.MustParseGraph(tt.graph)
+			c := analyses.BuildDefUseChains(g.Entries, g.IDs(), g.NodesPI(), defUse{g})
+			sf := analyses.RunSparse(c, union, derivedFrom(defUse{g}), analyses.NewVarSet(), analyses.NewVarSet("?"))
+			for d, want := range tt.defs {
+				if got := sf.Of(d).(analyses.VarSet).Sorted(); !reflect.DeepEqual(got, want) {
+					t.Errorf("Of(%v) = %v, want %v", d, got, want)
+				}
+			}
+			if got := sf.At(tt.use).(analyses.VarSet).Sorted(); !reflect.DeepEqual(got, tt.at) {
+				t.Errorf("At(%v) = %v, want %v", tt.use, got, tt.at)
+			}
+		})
+	}
+}
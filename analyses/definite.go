@@ -0,0 +1,24 @@
+package analyses
+
+import dfa "github.com/skius/dataflowanalysis"
+
+// DefiniteAssignmentProblem returns the forward must-analysis of the variables assigned on every path from the entry
+func DefiniteAssignmentProblem(ids []int, du DefUse) *Problem {
+	return &Problem{
+		Merge: func(f1, f2 dfa.Fact) dfa.Fact {
+			return f1.(VarSet).Intersect(f2.(VarSet))
+		},
+		Flow: func(f dfa.Fact, n dfa.NodePI) dfa.Fact {
+			in := f.(VarSet)
+			return in.Union(NewVarSet(du.Defs(n.Label())...))
+		},
+		Initial:  allVars(ids, du),
+		Boundary: make(VarSet),
+	}
+}
+
+// DefiniteAssignment computes the variables definitely assigned before and after each node
+func DefiniteAssignment(entryIds []int, ids []int, idToNode map[int]dfa.NodePI, du DefUse) VarSets {
+	in, out := DefiniteAssignmentProblem(ids, du).Solve(entryIds, ids, idToNode)
+	return toVarSets(in, out)
+}
@@ -0,0 +1,66 @@
+package analyses
+
+import dfa "github.com/skius/dataflowanalysis"
+
+func intersectExprs(f1, f2 dfa.Fact) dfa.Fact {
+	return f1.(ExprSet).Intersect(f2.(ExprSet))
+}
+
+// AvailableExpressionsProblem returns the forward must-analysis of the expressions that have been evaluated on
+// every path and not invalidated since
+func AvailableExpressionsProblem(ids []int, eu ExprDefUse) *Problem {
+	flow := func(f dfa.Fact, n dfa.NodePI) dfa.Fact {
+		in := f.(ExprSet)
+		kill := NewVarSet(eu.Defs(n.Label())...)
+
+		// An expression reading a variable the node assigns is not available afterwards
+		gen := make(ExprSet)
+		for _, e := range eu.Exprs(n.Label()) {
+			gen[e.Text] = e
+		}
+		return in.Union(gen).ExceptUsing(kill)
+	}
+
+	return &Problem{
+		Merge:    intersectExprs,
+		Flow:     flow,
+		Initial:  allExprs(ids, eu),
+		Boundary: make(ExprSet),
+	}
+}
+
+// AvailableExpressions computes the expressions available before and after each node
+func AvailableExpressions(entryIds []int, ids []int, idToNode map[int]dfa.NodePI, eu ExprDefUse) ExprSets {
+	in, out := AvailableExpressionsProblem(ids, eu).Solve(entryIds, ids, idToNode)
+	return toExprSets(in, out)
+}
+
+// VeryBusyExpressionsProblem returns the backward must-analysis of the expressions that are evaluated on every
+// path before any of their variables is reassigned
+func VeryBusyExpressionsProblem(ids []int, eu ExprDefUse) *Problem {
+	flow := func(f dfa.Fact, n dfa.NodePI) dfa.Fact {
+		out := f.(ExprSet)
+		kill := NewVarSet(eu.Defs(n.Label())...)
+
+		// The node evaluates its expressions before assigning
+		gen := make(ExprSet)
+		for _, e := range eu.Exprs(n.Label()) {
+			gen[e.Text] = e
+		}
+		return gen.Union(out.ExceptUsing(kill))
+	}
+
+	return &Problem{
+		Backward: true,
+		Merge:    intersectExprs,
+		Flow:     flow,
+		Initial:  allExprs(ids, eu),
+		Boundary: make(ExprSet),
+	}
+}
+
+// VeryBusyExpressions computes the expressions very busy before and after each node
+func VeryBusyExpressions(ids []int, idToNode map[int]dfa.NodePI, eu ExprDefUse) ExprSets {
+	in, out := VeryBusyExpressionsProblem(ids, eu).Solve(dfa.ExitsPI(ids, idToNode), ids, idToNode)
+	return toExprSets(in, out)
+}
@@ -0,0 +1,27 @@
+package analyses
+
+import dfa "github.com/skius/dataflowanalysis"
+
+// LiveVariablesProblem returns the backward may-analysis of the variables that may be read before being reassigned
+func LiveVariablesProblem(du DefUse) *Problem {
+	return &Problem{
+		Backward: true,
+		Merge: func(f1, f2 dfa.Fact) dfa.Fact {
+			return f1.(VarSet).Union(f2.(VarSet))
+		},
+		Flow: func(f dfa.Fact, n dfa.NodePI) dfa.Fact {
+			out := f.(VarSet)
+			gen := NewVarSet(du.Uses(n.Label())...)
+			kill := NewVarSet(du.Defs(n.Label())...)
+			return gen.Union(out.Except(kill))
+		},
+		Initial:  make(VarSet),
+		Boundary: make(VarSet),
+	}
+}
+
+// LiveVariables computes the variables live before and after each node
+func LiveVariables(ids []int, idToNode map[int]dfa.NodePI, du DefUse) VarSets {
+	in, out := LiveVariablesProblem(du).Solve(dfa.ExitsPI(ids, idToNode), ids, idToNode)
+	return toVarSets(in, out)
+}
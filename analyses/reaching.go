@@ -0,0 +1,47 @@
+package analyses
+
+import dfa "github.com/skius/dataflowanalysis"
+
+// ReachingDefinitionsProblem returns the forward may-analysis of the Definitions that may reach each node.
+// Every variable of the graph reaches the entries as an Uninitialized pseudo-definition.
+func ReachingDefinitionsProblem(ids []int, du DefUse) *Problem {
+	entry := make(DefSet)
+	for v := range allVars(ids, du) {
+		entry[Definition{Var: v, Label: Uninitialized}] = struct{}{}
+	}
+
+	flow := func(f dfa.Fact, n dfa.NodePI) dfa.Fact {
+		in := f.(DefSet)
+		defs := du.Defs(n.Label())
+		if len(defs) == 0 {
+			return in
+		}
+		killed := NewVarSet(defs...)
+
+		out := make(DefSet, len(in)+len(defs))
+		for d := range in {
+			if !killed.Contains(d.Var) {
+				out[d] = struct{}{}
+			}
+		}
+		for _, v := range defs {
+			out[Definition{Var: v, Label: n.Label()}] = struct{}{}
+		}
+		return out
+	}
+
+	return &Problem{
+		Merge: func(f1, f2 dfa.Fact) dfa.Fact {
+			return f1.(DefSet).Union(f2.(DefSet))
+		},
+		Flow:     flow,
+		Initial:  make(DefSet),
+		Boundary: entry,
+	}
+}
+
+// ReachingDefinitions computes the Definitions that may reach each node
+func ReachingDefinitions(entryIds []int, ids []int, idToNode map[int]dfa.NodePI, du DefUse) DefSets {
+	in, out := ReachingDefinitionsProblem(ids, du).Solve(entryIds, ids, idToNode)
+	return toDefSets(in, out)
+}
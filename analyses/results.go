@@ -0,0 +1,54 @@
+package analyses
+
+import dfa "github.com/skius/dataflowanalysis"
+
+// VarSets holds the per-node results of an analysis over variables
+type VarSets struct {
+	In  map[int]VarSet // The facts before each node
+	Out map[int]VarSet // The facts after each node
+}
+
+// DefSets holds the per-node results of an analysis over definitions
+type DefSets struct {
+	In  map[int]DefSet // The facts before each node
+	Out map[int]DefSet // The facts after each node
+}
+
+// ExprSets holds the per-node results of an analysis over expressions
+type ExprSets struct {
+	In  map[int]ExprSet // The facts before each node
+	Out map[int]ExprSet // The facts after each node
+}
+
+func toVarSets(in, out map[int]dfa.Fact) VarSets {
+	res := VarSets{In: make(map[int]VarSet, len(in)), Out: make(map[int]VarSet, len(out))}
+	for k, v := range in {
+		res.In[k] = v.(VarSet)
+	}
+	for k, v := range out {
+		res.Out[k] = v.(VarSet)
+	}
+	return res
+}
+
+func toDefSets(in, out map[int]dfa.Fact) DefSets {
+	res := DefSets{In: make(map[int]DefSet, len(in)), Out: make(map[int]DefSet, len(out))}
+	for k, v := range in {
+		res.In[k] = v.(DefSet)
+	}
+	for k, v := range out {
+		res.Out[k] = v.(DefSet)
+	}
+	return res
+}
+
+func toExprSets(in, out map[int]dfa.Fact) ExprSets {
+	res := ExprSets{In: make(map[int]ExprSet, len(in)), Out: make(map[int]ExprSet, len(out))}
+	for k, v := range in {
+		res.In[k] = v.(ExprSet)
+	}
+	for k, v := range out {
+		res.Out[k] = v.(ExprSet)
+	}
+	return res
+}
@@ -0,0 +1,247 @@
+package analyses
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A VarSet is a set of variables
+type VarSet map[string]struct{}
+
+// NewVarSet returns the set of the given variables
+func NewVarSet(vars ...string) VarSet {
+	s := make(VarSet, len(vars))
+	for _, v := range vars {
+		s[v] = struct{}{}
+	}
+	return s
+}
+
+func (s VarSet) Contains(v string) bool {
+	_, ok := s[v]
+	return ok
+}
+
+func (s VarSet) Union(s2 VarSet) VarSet {
+	u := make(VarSet, len(s)+len(s2))
+	for k := range s {
+		u[k] = struct{}{}
+	}
+	for k := range s2 {
+		u[k] = struct{}{}
+	}
+	return u
+}
+
+func (s VarSet) Intersect(s2 VarSet) VarSet {
+	u := make(VarSet)
+	for k := range s {
+		if s2.Contains(k) {
+			u[k] = struct{}{}
+		}
+	}
+	return u
+}
+
+func (s VarSet) Except(s2 VarSet) VarSet {
+	u := make(VarSet, len(s))
+	for k := range s {
+		if !s2.Contains(k) {
+			u[k] = struct{}{}
+		}
+	}
+	return u
+}
+
+// Sorted returns the variables in ascending order
+func (s VarSet) Sorted() []string {
+	vars := make([]string, 0, len(s))
+	for k := range s {
+		vars = append(vars, k)
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+func (s VarSet) Equals(otherF dfa.Fact) bool {
+	other := otherF.(VarSet)
+	if len(s) != len(other) {
+		return false
+	}
+	for k := range s {
+		if !other.Contains(k) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s VarSet) String() string {
+	return "{ " + strings.Join(s.Sorted(), ", ") + " }"
+}
+
+// Uninitialized is the label of the pseudo-definitions that model a variable reaching from the entry unassigned
+const Uninitialized = -1 << 31
+
+// A Definition is the assignment of Var at the node with label Label
+type Definition struct {
+	Var   string
+	Label int
+}
+
+func (d Definition) String() string {
+	if d.Label == Uninitialized {
+		return "(" + d.Var + ", ?)"
+	}
+	return "(" + d.Var + ", " + strconv.Itoa(d.Label) + ")"
+}
+
+// A DefSet is a set of Definitions
+type DefSet map[Definition]struct{}
+
+func (s DefSet) Contains(d Definition) bool {
+	_, ok := s[d]
+	return ok
+}
+
+func (s DefSet) Union(s2 DefSet) DefSet {
+	u := make(DefSet, len(s)+len(s2))
+	for k := range s {
+		u[k] = struct{}{}
+	}
+	for k := range s2 {
+		u[k] = struct{}{}
+	}
+	return u
+}
+
+// Of returns the Definitions of variable v
+func (s DefSet) Of(v string) []Definition {
+	defs := make([]Definition, 0)
+	for d := range s {
+		if d.Var == v {
+			defs = append(defs, d)
+		}
+	}
+	sortDefs(defs)
+	return defs
+}
+
+// Sorted returns the Definitions ordered by variable, then label
+func (s DefSet) Sorted() []Definition {
+	defs := make([]Definition, 0, len(s))
+	for d := range s {
+		defs = append(defs, d)
+	}
+	sortDefs(defs)
+	return defs
+}
+
+func (s DefSet) Equals(otherF dfa.Fact) bool {
+	other := otherF.(DefSet)
+	if len(s) != len(other) {
+		return false
+	}
+	for k := range s {
+		if !other.Contains(k) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s DefSet) String() string {
+	defs := s.Sorted()
+	strs := make([]string, len(defs))
+	for i, d := range defs {
+		strs[i] = d.String()
+	}
+	return "{ " + strings.Join(strs, ", ") + " }"
+}
+
+func sortDefs(defs []Definition) {
+	sort.Slice(defs, func(i, j int) bool {
+		if defs[i].Var != defs[j].Var {
+			return defs[i].Var < defs[j].Var
+		}
+		return defs[i].Label < defs[j].Label
+	})
+}
+
+// An ExprSet is a set of expressions, keyed by their text
+type ExprSet map[string]Expr
+
+func (s ExprSet) Contains(text string) bool {
+	_, ok := s[text]
+	return ok
+}
+
+func (s ExprSet) Union(s2 ExprSet) ExprSet {
+	u := make(ExprSet, len(s)+len(s2))
+	for k, v := range s {
+		u[k] = v
+	}
+	for k, v := range s2 {
+		u[k] = v
+	}
+	return u
+}
+
+func (s ExprSet) Intersect(s2 ExprSet) ExprSet {
+	u := make(ExprSet)
+	for k, v := range s {
+		if s2.Contains(k) {
+			u[k] = v
+		}
+	}
+	return u
+}
+
+// ExceptUsing returns the expressions that read none of the given variables
+func (s ExprSet) ExceptUsing(vars VarSet) ExprSet {
+	u := make(ExprSet, len(s))
+	for k, v := range s {
+		if !readsAny(v, vars) {
+			u[k] = v
+		}
+	}
+	return u
+}
+
+// Sorted returns the texts of the expressions in ascending order
+func (s ExprSet) Sorted() []string {
+	texts := make([]string, 0, len(s))
+	for k := range s {
+		texts = append(texts, k)
+	}
+	sort.Strings(texts)
+	return texts
+}
+
+func (s ExprSet) Equals(otherF dfa.Fact) bool {
+	other := otherF.(ExprSet)
+	if len(s) != len(other) {
+		return false
+	}
+	for k := range s {
+		if !other.Contains(k) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s ExprSet) String() string {
+	return "{ " + strings.Join(s.Sorted(), ", ") + " }"
+}
+
+func readsAny(e Expr, vars VarSet) bool {
+	for _, v := range e.Vars {
+		if vars.Contains(v) {
+			return true
+		}
+	}
+	return false
+}
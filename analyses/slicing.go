@@ -0,0 +1,141 @@
+package analyses
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"sort"
+)
+
+// A Criterion selects the value of Var at the node Label, the starting point of a slice
+type Criterion struct {
+	Label int
+	Var   string
+}
+
+// A PDG is the program dependence graph of a graph: every node depends on the Definitions it may read and on the
+// branch outcomes deciding whether it executes
+type PDG struct {
+	Chains     *DefUseChains            // The data dependences
+	Control    map[int][]dfa.ControlDep // The control dependences of each node
+	Data       map[int][]int            // The nodes each node is data dependent on, sorted
+	defUse     DefUse
+	dependents map[int][]int // Reverse data and control dependences
+}
+
+// BuildPDG computes the program dependence graph, data dependences are found using def-use chains and control
+// dependences using post-dominators
+func BuildPDG(entryIds []int, ids []int, idToNode map[int]dfa.Node, du DefUse) *PDG {
+	idToNodePI := dfa.PathInsensitive(idToNode)
+	p := &PDG{
+		Chains:     BuildDefUseChains(entryIds, ids, idToNodePI, du),
+		Control:    dfa.ControlDependences(ids, idToNode),
+		Data:       make(map[int][]int, len(ids)),
+		defUse:     du,
+		dependents: make(map[int][]int, len(ids)),
+	}
+
+	for _, id := range ids {
+		deps := make(map[int]bool)
+		for _, u := range p.Chains.UsesAt[id] {
+			for _, d := range p.Chains.DefsOf[u] {
+				if d.Label != Uninitialized {
+					deps[d.Label] = true
+				}
+			}
+		}
+		p.Data[id] = sortedKeys(deps)
+		for _, dep := range p.Data[id] {
+			p.dependents[dep] = appendUniqueInt(p.dependents[dep], id)
+		}
+		for _, c := range p.Control[id] {
+			p.dependents[c.Label] = appendUniqueInt(p.dependents[c.Label], id)
+		}
+	}
+	return p
+}
+
+// BackwardSlice returns the nodes that may affect the value of the Criterion's variable at its node, i.e. the
+// Definitions of it reaching the node and the branches deciding whether the node executes, closed under
+// dependences. The Criterion's node is part of the slice. The slice is sorted.
+func (p *PDG) BackwardSlice(c Criterion) []int {
+	inSlice := map[int]bool{c.Label: true}
+	worklist := make([]int, 0)
+	add := func(id int) {
+		if !inSlice[id] {
+			inSlice[id] = true
+			worklist = append(worklist, id)
+		}
+	}
+
+	for _, d := range p.Chains.Reaching(c.Label, c.Var) {
+		if d.Label != Uninitialized {
+			add(d.Label)
+		}
+	}
+	for _, dep := range p.Control[c.Label] {
+		add(dep.Label)
+	}
+
+	for len(worklist) > 0 {
+		id := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		for _, dep := range p.Data[id] {
+			add(dep)
+		}
+		for _, dep := range p.Control[id] {
+			add(dep.Label)
+		}
+	}
+	return sortedKeys(inSlice)
+}
+
+// ForwardSlice returns the nodes that may be affected by the value of the Criterion's variable at its node: if the
+// node assigns the variable, the nodes reading that Definition, if it reads the variable, everything depending on
+// the node, closed under dependences. The Criterion's node is part of the slice. The slice is sorted.
+func (p *PDG) ForwardSlice(c Criterion) []int {
+	inSlice := map[int]bool{c.Label: true}
+	worklist := make([]int, 0)
+	add := func(id int) {
+		if !inSlice[id] {
+			inSlice[id] = true
+			worklist = append(worklist, id)
+		}
+	}
+
+	if NewVarSet(p.defUse.Defs(c.Label)...).Contains(c.Var) {
+		for _, u := range p.Chains.UsesOf[Definition{Var: c.Var, Label: c.Label}] {
+			add(u.Label)
+		}
+	}
+	if NewVarSet(p.defUse.Uses(c.Label)...).Contains(c.Var) {
+		for _, dep := range p.dependents[c.Label] {
+			add(dep)
+		}
+	}
+
+	for len(worklist) > 0 {
+		id := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		for _, dep := range p.dependents[id] {
+			add(dep)
+		}
+	}
+	return sortedKeys(inSlice)
+}
+
+func sortedKeys(m map[int]bool) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func appendUniqueInt(xs []int, x int) []int {
+	for _, y := range xs {
+		if y == x {
+			return xs
+		}
+	}
+	return append(xs, x)
+}
@@ -0,0 +1,64 @@
+package analyses_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/analyses"
+	"reflect"
+	"testing"
+)
+
+func TestSlice(t *testing.T) {
+	// A loop with the head 3 around a diamond branching at 4 and joining at 6
+	g := dfa.MustParseGraph(`entry 1; 1 -> 2 -> 3 -> 4 -> 6 -> 3; 4 -T-> 5 -> 6; 3 -T-> 7
+		1 "i = 0"; 2 "s = 0"; 3 "i == 9"; 4 "i == 5"; 5 "s = i"; 6 "i = i + 1"; 7 "print s"`)
+	pdg := analyses.BuildPDG(g.Entries, g.IDs(), g.Nodes(), defUse{g})
+
+	if got, want := pdg.Data[5], []int{1, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Data[5] = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name     string
+		c        analyses.Criterion
+		backward []int
+		forward  []int
+	}{
+		{
+			// i depends on its definitions and on the loop deciding whether 6 executes, not on the diamond
+			name:     "loop counter",
+			c:        analyses.Criterion{Label: 6, Var: "i"},
+			backward: []int{1, 3, 6},
+			forward:  []int{3, 4, 5, 6, 7},
+		},
+		{
+			name:     "join of the diamond",
+			c:        analyses.Criterion{Label: 7, Var: "s"},
+			backward: []int{1, 2, 3, 4, 5, 6, 7},
+			forward:  []int{7},
+		},
+		{
+			// The initial s only reaches the print, on the paths where 5 does not overwrite it
+			name:     "initial sum",
+			c:        analyses.Criterion{Label: 2, Var: "s"},
+			backward: []int{2},
+			forward:  []int{2, 7},
+		},
+		{
+			name:     "branch in the loop",
+			c:        analyses.Criterion{Label: 4, Var: "i"},
+			backward: []int{1, 3, 4, 6},
+			forward:  []int{4, 5, 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pdg.BackwardSlice(tt.c); !reflect.DeepEqual(got, tt.backward) {
+				t.Errorf("BackwardSlice(%+v) = %v, want %v", tt.c, got, tt.backward)
+			}
+			if got := pdg.ForwardSlice(tt.c); !reflect.DeepEqual(got, tt.forward) {
+				t.Errorf("ForwardSlice(%+v) = %v, want %v", tt.c, got, tt.forward)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package analyses
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"sort"
+)
+
+// SparseFacts holds the result of RunSparse: a fact per Definition instead of a map of all variables per node
+type SparseFacts struct {
+	Defs map[Definition]dfa.Fact // The fact of the value assigned by each Definition
+
+	chains  *DefUseChains
+	merge   func(dfa.Fact, dfa.Fact) dfa.Fact
+	initial dfa.Fact
+	entry   dfa.Fact
+}
+
+// Of returns the fact of the Definition, Uninitialized pseudo-definitions have the entry fact
+func (sf *SparseFacts) Of(d Definition) dfa.Fact {
+	if d.Label == Uninitialized {
+		return sf.entry
+	}
+	if f, ok := sf.Defs[d]; ok {
+		return f
+	}
+	return sf.initial
+}
+
+// At returns the merged fact of the Definitions reaching the Use
+func (sf *SparseFacts) At(u Use) dfa.Fact {
+	defs := sf.chains.DefsOf[u]
+	facts := make([]dfa.Fact, len(defs))
+	for i, d := range defs {
+		facts[i] = sf.Of(d)
+	}
+	if len(facts) == 0 {
+		return sf.initial
+	}
+	fact := facts[0]
+	for _, f := range facts[1:] {
+		fact = sf.merge(fact, f)
+	}
+	return fact
+}
+
+// RunSparse computes a per-variable data-flow analysis over def-use chains. Instead of flowing a fact for every
+// variable through every node, each Definition has a single fact, which is only propagated to the nodes using it.
+// The work done is thus proportional to the number of def-use edges, not to nodes times variables.
+//
+// eval returns the facts of the variables a node defines, given the merged facts of the variables it uses.
+// initial is the fact of Definitions not evaluated yet, entry the fact of the Uninitialized pseudo-definitions.
+func RunSparse(
+	chains *DefUseChains,
+	merge func(dfa.Fact, dfa.Fact) dfa.Fact, // Meet operator
+	eval func(label int, operands map[string]dfa.Fact) map[string]dfa.Fact, // Evaluates a node's definitions
+	initial dfa.Fact,
+	entry dfa.Fact,
+) *SparseFacts {
+	sf := &SparseFacts{
+		Defs:    make(map[Definition]dfa.Fact),
+		chains:  chains,
+		merge:   merge,
+		initial: initial,
+		entry:   entry,
+	}
+
+	// Only nodes defining something need evaluating, starting in label order for reproducibility
+	worklist := make([]int, 0, len(chains.DefsAt))
+	queued := make(map[int]bool, len(chains.DefsAt))
+	for label, defs := range chains.DefsAt {
+		if len(defs) > 0 {
+			worklist = append(worklist, label)
+			queued[label] = true
+		}
+	}
+	sort.Ints(worklist)
+
+	for len(worklist) > 0 {
+		label := worklist[0]
+		worklist = worklist[1:]
+		queued[label] = false
+
+		operands := make(map[string]dfa.Fact, len(chains.UsesAt[label]))
+		for _, u := range chains.UsesAt[label] {
+			operands[u.Var] = sf.At(u)
+		}
+
+		results := eval(label, operands)
+		for _, d := range chains.DefsAt[label] {
+			res, ok := results[d.Var]
+			if !ok || res.Equals(sf.Of(d)) {
+				continue
+			}
+			sf.Defs[d] = res
+
+			// The value changed, re-evaluate the nodes using it
+			for _, u := range chains.UsesOf[d] {
+				if len(chains.DefsAt[u.Label]) > 0 && !queued[u.Label] {
+					worklist = append(worklist, u.Label)
+					queued[u.Label] = true
+				}
+			}
+		}
+	}
+
+	return sf
+}
@@ -0,0 +1,313 @@
+package dataflowanalysis_test
+
+import (
+	"fmt"
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/analyses"
+	"github.com/skius/dataflowanalysis/examples/stringlang/cfgadapter"
+	"github.com/skius/dataflowanalysis/randcfg"
+	"github.com/skius/stringlang"
+	"github.com/skius/stringlang/ast"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A benchGraph is a graph of the benchmark corpus with the variables its nodes define and use
+type benchGraph struct {
+	name     string
+	entryIds []int
+	ids      []int
+	idToNode map[int]dfa.Node
+	nodesPI  map[int]dfa.NodePI
+	du       analyses.DefUse
+}
+
+var (
+	corpusOnce  sync.Once
+	benchCorpus []*benchGraph
+)
+
+// corpus returns the graphs the benchmarks run on: generated shapes that stress particular parts of the solvers, and
+// stringlang programs
+func corpus(b *testing.B) []*benchGraph {
+	corpusOnce.Do(func() {
+		benchCorpus = []*benchGraph{
+			fromGraph("ladder-250", ladder(250)),
+			fromGraph("loopnest-20", loopNest(20, 10)),
+			fromGraph("switch-250", fanOut(250)),
+			fromGraph("random-500", randcfg.Generate(1, randcfg.Config{Nodes: 500, LoopDepth: 4, Branching: 4})),
+			fromProgram("stringlang-generated", generatedProgram(200)),
+		}
+
+		files, _ := filepath.Glob(filepath.Join("examples", "stringlang", "*", "program.stringlang"))
+		for _, file := range files {
+			src, err := ioutil.ReadFile(file)
+			if err != nil {
+				panic(err)
+			}
+			benchCorpus = append(benchCorpus, fromProgram("stringlang-"+filepath.Base(filepath.Dir(file)), string(src)))
+		}
+	})
+	if len(benchCorpus) == 0 {
+		b.Skip("empty corpus")
+	}
+	return benchCorpus
+}
+
+// fromGraph fills a generated graph with random assignments over a few variables
+func fromGraph(name string, g *dfa.Graph) *benchGraph {
+	vars := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	stmts := randcfg.Assignments(vars, 3)
+	r := rand.New(rand.NewSource(1))
+	for _, id := range g.IDs() {
+		g.SetStmt(id, stmts(r, g.Node(id)))
+	}
+	return &benchGraph{
+		name:     name,
+		entryIds: g.Entries,
+		ids:      g.IDs(),
+		idToNode: g.Nodes(),
+		nodesPI:  g.NodesPI(),
+		du:       randcfg.DefUse(g),
+	}
+}
+
+func fromProgram(name string, src string) *benchGraph {
+	expr, err := stringlang.Parse([]byte(src))
+	if err != nil {
+		panic(fmt.Sprintf("%s: %v", name, err))
+	}
+	g := cfgadapter.New(expr.(ast.Program))
+	return &benchGraph{
+		name:     name,
+		entryIds: []int{g.Entry},
+		ids:      g.IDs,
+		idToNode: g.Nodes,
+		nodesPI:  g.NodesPI,
+		du:       g.DefUse(),
+	}
+}
+
+// ladder returns a sequence of n if-else diamonds
+func ladder(n int) *dfa.Graph {
+	g := dfa.NewGraph()
+	g.SetEntry(1)
+	for i := 0; i < n; i++ {
+		cond := 1 + 3*i
+		g.AddEdge(cond, cond+1, false)
+		g.AddEdge(cond, cond+2, true)
+		g.AddEdge(cond+1, cond+3, false)
+		g.AddEdge(cond+2, cond+3, false)
+	}
+	return g
+}
+
+// loopNest returns depth nested while loops, each with a body of width nodes before the inner loop
+func loopNest(depth, width int) *dfa.Graph {
+	g := dfa.NewGraph()
+	g.SetEntry(1)
+	last := 0
+	var loop func(d int) (head, exit int)
+	loop = func(d int) (head, exit int) {
+		last++
+		head = last
+		prev, taken := head, true
+		for i := 0; i < width; i++ {
+			last++
+			g.AddEdge(prev, last, taken)
+			prev, taken = last, false
+		}
+		if d > 1 {
+			innerHead, innerExit := loop(d - 1)
+			g.AddEdge(prev, innerHead, false)
+			prev = innerExit
+		}
+		g.AddEdge(prev, head, false)
+		last++
+		g.AddEdge(head, last, false)
+		return head, last
+	}
+	loop(depth)
+	return g
+}
+
+// fanOut returns a switch with the number of arms inside a loop, so that the facts of all arms are merged repeatedly
+func fanOut(arms int) *dfa.Graph {
+	g := dfa.NewGraph()
+	g.SetEntry(1)
+	head, sw, join, exit := 1, 2, arms+3, arms+4
+	g.AddEdge(head, sw, true)
+	g.AddEdge(head, exit, false)
+	for i := 0; i < arms; i++ {
+		g.AddEdge(sw, 3+i, i > 0)
+		g.AddEdge(3+i, join, false)
+	}
+	g.AddEdge(join, head, false)
+	return g
+}
+
+// generatedProgram returns a stringlang program of n random statements, nested in ifs and whiles
+func generatedProgram(n int) string {
+	r := rand.New(rand.NewSource(1))
+	v := func() string {
+		return "v" + fmt.Sprint(r.Intn(6))
+	}
+	var block func(depth int) string
+	block = func(depth int) string {
+		stmts := make([]string, 0)
+		for len(stmts) == 0 || (n > 0 && r.Intn(4) > 0) {
+			n--
+			switch roll := r.Intn(6); {
+			case roll == 0 && depth < 4:
+				stmts = append(stmts, "if ("+v()+" == \"x\") { "+block(depth+1)+" } else { "+block(depth+1)+" }")
+			case roll == 1 && depth < 4:
+				stmts = append(stmts, "while ("+v()+" != \"xxx\") { "+block(depth+1)+" }")
+			default:
+				stmts = append(stmts, v()+" = "+v()+" + \"x\"")
+			}
+		}
+		return strings.Join(stmts, ";\n")
+	}
+
+	stmts := make([]string, 0)
+	for n > 0 {
+		stmts = append(stmts, block(0))
+	}
+	return strings.Join(stmts, ";\n") + ";\nv0"
+}
+
+// A constValue is the value of a variable in constant propagation: a constant, or varying. Variables that are
+// absent from a constEnv have no value yet.
+type constValue struct {
+	varying bool
+	c       uint64
+}
+
+// A constEnv is the Fact of a constant propagation that treats every assignment as an uninterpreted function of the
+// node and the values of its uses, so that it runs on any graph with a DefUse
+type constEnv map[string]constValue
+
+func (e constEnv) Equals(f dfa.Fact) bool {
+	o := f.(constEnv)
+	if len(e) != len(o) {
+		return false
+	}
+	for v, x := range e {
+		if y, ok := o[v]; !ok || x != y {
+			return false
+		}
+	}
+	return true
+}
+
+func (e constEnv) String() string {
+	vars := make([]string, 0, len(e))
+	for v, x := range e {
+		if x.varying {
+			vars = append(vars, v+"=T")
+		} else {
+			vars = append(vars, fmt.Sprintf("%s=%d", v, x.c))
+		}
+	}
+	sort.Strings(vars)
+	return "{" + strings.Join(vars, ", ") + "}"
+}
+
+func mergeConst(f1, f2 dfa.Fact) dfa.Fact {
+	e1, e2 := f1.(constEnv), f2.(constEnv)
+	res := make(constEnv, len(e1)+len(e2))
+	for v, x := range e1 {
+		res[v] = x
+	}
+	for v, y := range e2 {
+		if x, ok := res[v]; ok && x != y {
+			res[v] = constValue{varying: true}
+		} else {
+			res[v] = y
+		}
+	}
+	return res
+}
+
+func flowConst(du analyses.DefUse) func(dfa.Fact, dfa.Node) (dfa.Fact, dfa.Fact) {
+	return func(f dfa.Fact, n dfa.Node) (dfa.Fact, dfa.Fact) {
+		in := f.(constEnv)
+		defs := du.Defs(n.Label())
+		if len(defs) == 0 {
+			return in, in
+		}
+
+		val := constValue{c: uint64(n.Label()) * 0x9e3779b97f4a7c15}
+		known := true
+		for i, u := range du.Uses(n.Label()) {
+			x, ok := in[u]
+			known = known && ok
+			val.varying = val.varying || x.varying
+			val.c ^= x.c * uint64(i+3)
+		}
+		if val.varying {
+			val.c = 0
+		}
+
+		out := make(constEnv, len(in)+len(defs))
+		for v, x := range in {
+			out[v] = x
+		}
+		for _, d := range defs {
+			if known || val.varying {
+				out[d] = val
+			} else {
+				delete(out, d)
+			}
+		}
+		return out, out
+	}
+}
+
+// runBench times solve on every graph of the corpus without options, reporting the time per node, and reports the
+// work per run from one more, untimed run with statistics
+func runBench(b *testing.B, solve func(g *benchGraph, opts ...dfa.Option)) {
+	for _, g := range corpus(b) {
+		g := g
+		b.Run(g.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				solve(g)
+			}
+			elapsed := time.Since(start)
+			b.StopTimer()
+
+			st := new(dfa.Stats)
+			solve(g, dfa.WithStats(st))
+			b.ReportMetric(float64(st.FlowEvals), "evals/op")
+			b.ReportMetric(float64(st.Merges), "merges/op")
+			b.ReportMetric(float64(elapsed.Nanoseconds())/float64(b.N*len(g.ids)), "ns/node")
+		})
+	}
+}
+
+func BenchmarkLiveVariables(b *testing.B) {
+	runBench(b, func(g *benchGraph, opts ...dfa.Option) {
+		analyses.LiveVariablesProblem(g.du).Solve(dfa.ExitsPI(g.ids, g.nodesPI), g.ids, g.nodesPI, opts...)
+	})
+}
+
+func BenchmarkReachingDefinitions(b *testing.B) {
+	runBench(b, func(g *benchGraph, opts ...dfa.Option) {
+		analyses.ReachingDefinitionsProblem(g.ids, g.du).Solve(g.entryIds, g.ids, g.nodesPI, opts...)
+	})
+}
+
+func BenchmarkConstantPropagation(b *testing.B) {
+	runBench(b, func(g *benchGraph, opts ...dfa.Option) {
+		dfa.RunForward(g.entryIds, g.ids, g.idToNode, mergeConst, flowConst(g.du), constEnv{}, constEnv{}, opts...)
+	})
+}
@@ -0,0 +1,238 @@
+// Command dfastep steps through the worklist of dfa.RunForward on a stringlang program in the terminal.
+//
+// Usage:
+//
+//	dfastep [-analysis reaching|definite] [-break 3,5] [-break-on-change] program.stringlang
+//
+// At every stop it shows the evaluated node's statement, its merged in fact, its out facts and the worklist, then
+// waits for a command, see help.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/analyses"
+	"github.com/skius/dataflowanalysis/examples/stringlang/cfgadapter"
+	"github.com/skius/stringlang"
+	"github.com/skius/stringlang/ast"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const help = `Commands:
+  s, <enter>  evaluate the next node
+  c           continue until a breakpoint
+  b <label>   toggle a breakpoint on the node
+  change      toggle breaking whenever an out fact changes
+  p <label>   print the current facts of the node
+  l           list the program
+  q           quit
+  h           show this help`
+
+// A stepper holds the state of the interactive session
+type stepper struct {
+	graph    *cfgadapter.Graph
+	input    *bufio.Scanner
+	breaks   map[int]bool
+	onChange bool
+	running  bool // Whether to continue until a breakpoint
+	last     map[int]*dfa.StepEvent
+}
+
+func main() {
+	analysis := flag.String("analysis", "reaching", "the analysis to run: reaching (definitions) or definite (assignment)")
+	breakAt := flag.String("break", "", "comma-separated labels to break at")
+	onChange := flag.Bool("break-on-change", false, "break whenever an out fact changes")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: dfastep [flags] program.stringlang")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	f, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	expr, err := stringlang.Parse(f)
+	if err != nil {
+		fail(err)
+	}
+	graph := cfgadapter.New(expr.(ast.Program))
+
+	var problem *analyses.Problem
+	switch *analysis {
+	case "reaching":
+		problem = analyses.ReachingDefinitionsProblem(graph.IDs, graph.DefUse())
+	case "definite":
+		problem = analyses.DefiniteAssignmentProblem(graph.IDs, graph.DefUse())
+	default:
+		fail(fmt.Errorf("unknown analysis %q", *analysis))
+	}
+
+	st := &stepper{
+		graph:    graph,
+		input:    bufio.NewScanner(os.Stdin),
+		breaks:   make(map[int]bool),
+		onChange: *onChange,
+		last:     make(map[int]*dfa.StepEvent),
+	}
+	if *breakAt != "" {
+		for _, l := range strings.Split(*breakAt, ",") {
+			label, err := strconv.Atoi(strings.TrimSpace(l))
+			if err != nil {
+				fail(err)
+			}
+			st.breaks[label] = true
+		}
+		// Breakpoints given up front mean the user wants to get to them
+		st.running = true
+	}
+
+	// The analyses are path-insensitive, so both branches get the same fact
+	flow := func(f dfa.Fact, n dfa.Node) (dfa.Fact, dfa.Fact) {
+		out := problem.Flow(f, n.(dfa.NodePI))
+		return out, out
+	}
+
+	st.list()
+	fmt.Println(help)
+	in, _, _ := dfa.RunForward([]int{graph.Entry}, graph.IDs, graph.Nodes, problem.Merge, flow, problem.Initial,
+		problem.Boundary, dfa.WithStepHook(st.step))
+
+	fmt.Println()
+	fmt.Println("Fixpoint reached, in facts:")
+	for _, id := range graph.IDs {
+		fmt.Printf("%3d: %v\n", id, in[id])
+	}
+}
+
+// step is the step hook, it shows the step and waits for commands if it is a stop
+func (st *stepper) step(e *dfa.StepEvent) {
+	st.last[e.Label] = e
+	changed := e.ChangedNotTaken || e.ChangedTaken
+	if st.running && !st.breaks[e.Label] && !(st.onChange && changed) {
+		return
+	}
+	st.running = false
+
+	fmt.Println()
+	fmt.Printf("Step %d: node %d\n", e.Step, e.Label)
+	st.show(e)
+	if len(e.Worklist) > 0 {
+		fmt.Println("  worklist:", e.Worklist, "next:", e.Worklist[0])
+	} else {
+		fmt.Println("  worklist: empty")
+	}
+
+	for {
+		fmt.Print("> ")
+		if !st.input.Scan() {
+			// No more input, run to the end
+			st.running = true
+			st.breaks = map[int]bool{}
+			st.onChange = false
+			return
+		}
+		fields := strings.Fields(st.input.Text())
+		cmd := ""
+		if len(fields) > 0 {
+			cmd = fields[0]
+		}
+
+		switch cmd {
+		case "", "s":
+			return
+		case "c":
+			st.running = true
+			return
+		case "b", "p":
+			if len(fields) != 2 {
+				fmt.Println("expected a label")
+				continue
+			}
+			label, err := strconv.Atoi(fields[1])
+			if _, ok := st.graph.Nodes[label]; err != nil || !ok {
+				fmt.Println("no node", fields[1])
+				continue
+			}
+			if cmd == "p" {
+				st.print(label)
+				continue
+			}
+			st.breaks[label] = !st.breaks[label]
+			fmt.Println("breakpoints:", st.breakpoints())
+		case "change":
+			st.onChange = !st.onChange
+			fmt.Println("break on change:", st.onChange)
+		case "l":
+			st.list()
+		case "q":
+			os.Exit(0)
+		default:
+			fmt.Println(help)
+		}
+	}
+}
+
+// show prints the statement and facts of an evaluation
+func (st *stepper) show(e *dfa.StepEvent) {
+	marker := func(changed bool) string {
+		if changed {
+			return " (changed)"
+		}
+		return ""
+	}
+
+	fmt.Printf("  %d: %v\n", e.Label, st.graph.Expr(e.Label))
+	fmt.Println("  in:       ", e.In)
+	if len(st.graph.Nodes[e.Label].SuccsTaken()) == 0 {
+		fmt.Printf("  out:       %v%s\n", e.OutNotTaken, marker(e.ChangedNotTaken))
+		return
+	}
+	fmt.Printf("  out false: %v%s\n", e.OutNotTaken, marker(e.ChangedNotTaken))
+	fmt.Printf("  out true:  %v%s\n", e.OutTaken, marker(e.ChangedTaken))
+}
+
+// print shows the facts of the node's last evaluation
+func (st *stepper) print(label int) {
+	e, ok := st.last[label]
+	if !ok {
+		fmt.Printf("  %d: %v\n", label, st.graph.Expr(label))
+		fmt.Println("  not evaluated yet")
+		return
+	}
+	fmt.Printf("  last evaluated in step %d\n", e.Step)
+	st.show(e)
+}
+
+func (st *stepper) list() {
+	for _, id := range st.graph.IDs {
+		marker := " "
+		if st.breaks[id] {
+			marker = "*"
+		}
+		fmt.Printf("%s%3d: %v\n", marker, id, st.graph.Expr(id))
+	}
+}
+
+func (st *stepper) breakpoints() []int {
+	labels := make([]int, 0, len(st.breaks))
+	for label, on := range st.breaks {
+		if on {
+			labels = append(labels, label)
+		}
+	}
+	sort.Ints(labels)
+	return labels
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "dfastep:", err)
+	os.Exit(1)
+}
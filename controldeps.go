@@ -0,0 +1,81 @@
+package dataflowanalysis
+
+import (
+	"sort"
+	"strconv"
+)
+
+// A ControlDep is a branch outcome a node is control dependent on: taking the Taken (or not-taken) edges of the node
+// Label decides whether the dependent node executes
+type ControlDep struct {
+	Label int
+	Taken bool
+}
+
+func (c ControlDep) String() string {
+	if c.Taken {
+		return strconv.Itoa(c.Label) + "T"
+	}
+	return strconv.Itoa(c.Label) + "F"
+}
+
+// ControlDependences computes the control dependences of every node (Ferrante, Ottenstein and Warren, "The Program
+// Dependence Graph and Its Use in Optimization"): a node depends on the outcome of a branch if it post-dominates one
+// of the branch's successors, but not the branch itself.
+// The post-dominators are rooted at the exits, so nodes that cannot reach an exit have no control dependences.
+// Nodes depending on no branch, e.g. those executed on every run, have an empty slice. Dependences are sorted.
+func ControlDependences(ids []int, idToNode map[int]Node) map[int][]ControlDep {
+	pdom := PostDominators(Exits(ids, idToNode), ids, idToNode)
+
+	deps := make(map[int][]ControlDep, len(ids))
+	seen := make(map[int]map[ControlDep]bool, len(ids))
+	for _, id := range ids {
+		deps[id] = []ControlDep{}
+		seen[id] = make(map[ControlDep]bool)
+	}
+
+	addEdge := func(from, to int, taken bool) {
+		if !pdom.Reachable(to) {
+			return
+		}
+		stop, hasStop := pdom.Idom(from)
+		if !pdom.Reachable(from) {
+			hasStop = false
+		}
+		dep := ControlDep{Label: from, Taken: taken}
+
+		// Every node on the post-dominator tree path from to up to, excluding, the immediate post-dominator of from
+		for runner, ok := to, true; ok && !(hasStop && runner == stop); runner, ok = pdom.Idom(runner) {
+			if !seen[runner][dep] {
+				seen[runner][dep] = true
+				deps[runner] = append(deps[runner], dep)
+			}
+		}
+	}
+
+	for _, id := range ids {
+		n := idToNode[id]
+		for _, succ := range n.SuccsNotTaken() {
+			addEdge(id, succ, false)
+		}
+		for _, succ := range n.SuccsTaken() {
+			addEdge(id, succ, true)
+		}
+	}
+
+	for _, ds := range deps {
+		sort.Slice(ds, func(i, j int) bool {
+			if ds[i].Label != ds[j].Label {
+				return ds[i].Label < ds[j].Label
+			}
+			return !ds[i].Taken && ds[j].Taken
+		})
+	}
+	return deps
+}
+
+// ControlDependencesPI computes the control dependences of every path-insensitive node, see ControlDependences.
+// All dependences are on not-taken edges, as the graph does not distinguish the outcomes of a branch.
+func ControlDependencesPI(ids []int, idToNode map[int]NodePI) map[int][]ControlDep {
+	return ControlDependences(ids, wrapPI(idToNode))
+}
@@ -1,5 +1,7 @@
 package dataflowanalysis
 
+import "sort"
+
 // Node represents a path-sensitive data-flow CFG
 type Node interface {
 	Label() int
@@ -34,6 +36,22 @@ func RunBackwardPI(
 	merge func(Fact, Fact) Fact, // Meet operator
 	flow func(Fact, NodePI) Fact, // Flow function
 	initialFlow Fact,
+	opts ...Option,
+) (in, out map[int]Fact) {
+	return RunBackwardPIFrom([]int{}, ids, idToNode, merge, flow, initialFlow, initialFlow, opts...)
+}
+
+// RunBackwardPIFrom computes a path-insensitive backward data-flow analysis, where exitFlow flows out of the nodes
+// in exitIds
+func RunBackwardPIFrom(
+	exitIds []int,
+	ids []int,
+	idToNode map[int]NodePI,
+	merge func(Fact, Fact) Fact, // Meet operator
+	flow func(Fact, NodePI) Fact, // Flow function
+	initialFlow Fact,
+	exitFlow Fact,
+	opts ...Option,
 ) (in, out map[int]Fact) {
 
 	idToNodeForward := make(map[int]Node, len(idToNode))
@@ -55,7 +73,8 @@ func RunBackwardPI(
 	}
 
 	// Can ignore the Taken out map because we have no Taken branches
-	inForward, outForwardNT, _ := RunForward([]int{}, ids, idToNodeForward, merge, flowWrapper, initialFlow, initialFlow)
+	inForward, outForwardNT, _ := RunForward(exitIds, ids, idToNodeForward, merge, flowWrapper, initialFlow, exitFlow,
+		append([]Option{reversed()}, opts...)...)
 
 	// The in flow at each node is the out flow of the reversed data-flow and vice-versa
 	return outForwardNT, inForward
@@ -102,6 +121,7 @@ func RunForwardPI(
 	flow func(Fact, NodePI) Fact, // Flow function
 	initialFlow Fact,
 	entryFlow Fact,
+	opts ...Option,
 ) (in, out map[int]Fact) {
 	idToNodePS := make(map[int]Node, len(idToNode))
 
@@ -120,7 +140,7 @@ func RunForwardPI(
 	}
 
 	// Can ignore the Taken out map because we have no Taken branches
-	inPS, outPSNT, _ := RunForward(entryIds, ids, idToNodePS, merge, flowWrapper, initialFlow, entryFlow)
+	inPS, outPSNT, _ := RunForward(entryIds, ids, idToNodePS, merge, flowWrapper, initialFlow, entryFlow, opts...)
 
 	// The in flow at each node is the out flow of the reversed data-flow and vice-versa
 	return inPS, outPSNT
@@ -135,81 +155,71 @@ func RunForward(
 	flow func(Fact, Node) (Fact, Fact), // Flow function
 	initialFlow Fact,
 	entryFlow Fact,
+	opts ...Option,
 ) (in, outNotTaken, outTaken map[int]Fact) {
-	// The number of nodes we are working with
-	n := len(ids)
-
-	// The in and out sets for each node
-	in = make(map[int]Fact, n)
-	outNotTaken = make(map[int]Fact, n)
-	outTaken = make(map[int]Fact, n)
+	cfg := newConfig(opts)
+	s := newForwardSolver(entryIds, ids, idToNode, merge, flow, initialFlow, entryFlow, cfg)
+	if cfg.table != nil {
+		s.roundRobin(ids, cfg.table, nil, nil)
+		return s.in, s.outNotTaken, s.outTaken
+	}
 
 	// map instead of set to avoid adding duplicates
-	worklist := make(map[int]struct{}, n)
-
-	isEntry := make(map[int]bool)
-
+	worklist := make(map[int]struct{}, len(ids))
 	for _, id := range ids {
-		in[id] = initialFlow
-		outNotTaken[id] = initialFlow
-		outTaken[id] = initialFlow
-
 		worklist[id] = struct{}{}
 	}
-
-	for _, id := range entryIds {
-		in[id] = entryFlow
-		isEntry[id] = true
+	if s.stats != nil && len(worklist) > s.stats.MaxWorklist {
+		s.stats.MaxWorklist = len(worklist)
+	}
+	s.worklist = func() []int {
+		pending := make([]int, 0, len(worklist))
+		for id := range worklist {
+			pending = append(pending, id)
+		}
+		sort.Ints(pending)
+		return pending
 	}
 
 	for len(worklist) > 0 {
-		// Pop a node off the worklist
+		// Pop a node off the worklist, the one with the smallest label if the steps are observed
 		var currNodeId int
+		first := true
 		for k := range worklist {
-			currNodeId = k
-			break
+			if s.stepHook == nil {
+				currNodeId = k
+				break
+			}
+			if first || k < currNodeId {
+				currNodeId = k
+				first = false
+			}
 		}
 		delete(worklist, currNodeId)
 
 		currNode := idToNode[currNodeId]
+		changedNotTaken, changedTaken := s.apply(currNodeId, s.mergeIn(currNodeId))
 
-		inFacts := make([]Fact, 0, len(currNode.PredsNotTaken())+len(currNode.PredsTaken())+1)
-		for _, pred := range currNode.PredsNotTaken() {
-			inFacts = append(inFacts, outNotTaken[pred])
-		}
-		for _, pred := range currNode.PredsTaken() {
-			inFacts = append(inFacts, outTaken[pred])
-		}
-
-		if isEntry[currNodeId] {
-			inFacts = append(inFacts, entryFlow)
-		}
-
-		inFact := mergeAll(merge, inFacts, initialFlow)
-
-		in[currNodeId] = inFact
-		outNotTakenFact, outTakenFact := flow(inFact, currNode)
-
-		if outNotTakenFact != nil && !outNotTakenFact.Equals(outNotTaken[currNodeId]) {
+		if changedNotTaken {
 			// Flow changed, add successors
 			for _, succ := range currNode.SuccsNotTaken() {
 				worklist[succ] = struct{}{}
 			}
-
-			outNotTaken[currNodeId] = outNotTakenFact
 		}
 
-		if outTakenFact != nil && !outTakenFact.Equals(outTaken[currNodeId]) {
+		if changedTaken {
 			// Flow changed, add successors
 			for _, succ := range currNode.SuccsTaken() {
 				worklist[succ] = struct{}{}
 			}
+		}
 
-			outTaken[currNodeId] = outTakenFact
+		if s.stats != nil && len(worklist) > s.stats.MaxWorklist {
+			s.stats.MaxWorklist = len(worklist)
 		}
 	}
 
-	return in, outNotTaken, outTaken
+	return s.in, s.outNotTaken, s.outTaken
 }
 
 //func RunAnalysis(
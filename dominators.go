@@ -0,0 +1,344 @@
+package dataflowanalysis
+
+import "sort"
+
+// A DomTree is the dominator tree of a graph, or its post-dominator tree if computed on the reversed graph.
+// Only nodes reachable from the entries (respectively, reaching the exits) are part of the tree.
+type DomTree struct {
+	v         *view
+	root      int     // Index of the virtual root, whose children are the entries
+	entries   []bool  // Whether an index is an entry, i.e. has the virtual root as predecessor
+	idom      []int   // Index of the immediate dominator, the virtual root for entries, -1 if unreachable
+	children  [][]int // Children in the dominator tree, sorted by label
+	pre, post []int   // Pre- and postorder numbers in the dominator tree, for constant-time dominance queries
+	frontiers [][]int // Dominance frontier of each index
+}
+
+// Dominators computes the dominator tree of the graph, rooted at the nodes in entryIds
+func Dominators(entryIds []int, ids []int, idToNode map[int]Node) *DomTree {
+	return newDomTree(newView(ids, idToNode), entryIds)
+}
+
+// DominatorsPI computes the dominator tree of the path-insensitive graph, rooted at the nodes in entryIds
+func DominatorsPI(entryIds []int, ids []int, idToNode map[int]NodePI) *DomTree {
+	return Dominators(entryIds, ids, wrapPI(idToNode))
+}
+
+// PostDominators computes the post-dominator tree of the graph, rooted at the nodes in exitIds
+func PostDominators(exitIds []int, ids []int, idToNode map[int]Node) *DomTree {
+	return newDomTree(newView(ids, idToNode).reversed(), exitIds)
+}
+
+// PostDominatorsPI computes the post-dominator tree of the path-insensitive graph, rooted at the nodes in exitIds
+func PostDominatorsPI(exitIds []int, ids []int, idToNode map[int]NodePI) *DomTree {
+	return PostDominators(exitIds, ids, wrapPI(idToNode))
+}
+
+// newDomTree runs the algorithm of Cooper, Harvey and Kennedy, "A Simple, Fast Dominance Algorithm"
+func newDomTree(v *view, entryIds []int) *DomTree {
+	n := len(v.labels)
+	t := new(DomTree)
+	t.v = v
+	t.root = n
+	t.entries = make([]bool, n+1)
+	for _, i := range v.indicesOf(entryIds) {
+		t.entries[i] = true
+	}
+
+	succs := func(i int) []int {
+		if i == t.root {
+			return v.indicesOf(entryIds)
+		}
+		return v.succs[i]
+	}
+
+	// Postorder numbers of a DFS from the virtual root, the reverse postorder is the processing order
+	postNum := make([]int, n+1)
+	for i := range postNum {
+		postNum[i] = -1
+	}
+	order := dfsPostorder(t.root, succs)
+	for num, i := range order {
+		postNum[i] = num
+	}
+
+	t.idom = make([]int, n+1)
+	for i := range t.idom {
+		t.idom[i] = -1
+	}
+	t.idom[t.root] = t.root
+
+	intersect := func(a, b int) int {
+		for a != b {
+			for postNum[a] < postNum[b] {
+				a = t.idom[a]
+			}
+			for postNum[b] < postNum[a] {
+				b = t.idom[b]
+			}
+		}
+		return a
+	}
+
+	changed := true
+	for changed {
+		changed = false
+		for k := len(order) - 2; k >= 0; k-- {
+			b := order[k]
+			newIdom := -1
+			for _, p := range t.predsOf(b) {
+				if t.idom[p] == -1 {
+					continue
+				}
+				if newIdom == -1 {
+					newIdom = p
+				} else {
+					newIdom = intersect(p, newIdom)
+				}
+			}
+			if t.idom[b] != newIdom {
+				t.idom[b] = newIdom
+				changed = true
+			}
+		}
+	}
+
+	t.children = make([][]int, n+1)
+	for i := 0; i < n; i++ {
+		if t.idom[i] != -1 {
+			t.children[t.idom[i]] = append(t.children[t.idom[i]], i)
+		}
+	}
+	for _, cs := range t.children {
+		sort.Slice(cs, func(a, b int) bool {
+			return t.label(cs[a]) < t.label(cs[b])
+		})
+	}
+
+	t.pre = make([]int, n+1)
+	t.post = make([]int, n+1)
+	preCtr, postCtr := 0, 0
+	t.walk(t.root, func(i int) {
+		t.pre[i] = preCtr
+		preCtr++
+	}, func(i int) {
+		t.post[i] = postCtr
+		postCtr++
+	})
+
+	t.frontiers = make([][]int, n+1)
+	for b := 0; b < n; b++ {
+		preds := t.predsOf(b)
+		if t.idom[b] == -1 || len(preds) < 2 {
+			continue
+		}
+		for _, p := range preds {
+			if t.idom[p] == -1 {
+				continue
+			}
+			for runner := p; runner != t.idom[b]; runner = t.idom[runner] {
+				t.frontiers[runner] = appendUnique(t.frontiers[runner], b)
+			}
+		}
+	}
+
+	return t
+}
+
+// predsOf returns the predecessors of i, including the virtual root for entries
+func (t *DomTree) predsOf(i int) []int {
+	if t.entries[i] {
+		return append([]int{t.root}, t.v.preds[i]...)
+	}
+	return t.v.preds[i]
+}
+
+func (t *DomTree) label(i int) int {
+	return t.v.labels[i]
+}
+
+// walk visits the dominator subtree of i in DFS order, calling pre before and post after the children of a node
+func (t *DomTree) walk(i int, pre, post func(int)) {
+	if pre != nil {
+		pre(i)
+	}
+	for _, c := range t.children[i] {
+		t.walk(c, pre, post)
+	}
+	if post != nil {
+		post(i)
+	}
+}
+
+func (t *DomTree) lookup(id int) (int, bool) {
+	i, ok := t.v.index[id]
+	if !ok || t.idom[i] == -1 {
+		return 0, false
+	}
+	return i, true
+}
+
+// Reachable returns whether the node is part of the tree
+func (t *DomTree) Reachable(id int) bool {
+	_, ok := t.lookup(id)
+	return ok
+}
+
+// Idom returns the immediate dominator of the node, ok is false for roots and unreachable nodes
+func (t *DomTree) Idom(id int) (idom int, ok bool) {
+	i, ok := t.lookup(id)
+	if !ok || t.idom[i] == t.root {
+		return 0, false
+	}
+	return t.label(t.idom[i]), true
+}
+
+// Dominates returns whether every path from an entry to b passes through a, every node dominates itself
+func (t *DomTree) Dominates(a, b int) bool {
+	i, okA := t.lookup(a)
+	j, okB := t.lookup(b)
+	if !okA || !okB {
+		return false
+	}
+	return t.pre[i] <= t.pre[j] && t.post[j] <= t.post[i]
+}
+
+// StrictlyDominates returns whether a dominates b and a != b
+func (t *DomTree) StrictlyDominates(a, b int) bool {
+	return a != b && t.Dominates(a, b)
+}
+
+// Roots returns the reachable entries, the roots of the tree
+func (t *DomTree) Roots() []int {
+	return t.v.labelsOf(t.children[t.root])
+}
+
+// Children returns the nodes immediately dominated by the node
+func (t *DomTree) Children(id int) []int {
+	i, ok := t.lookup(id)
+	if !ok {
+		return []int{}
+	}
+	return t.v.labelsOf(t.children[i])
+}
+
+// Visit runs pre and post over the tree in DFS order, pre before and post after the children of a node.
+// Either may be nil.
+func (t *DomTree) Visit(pre, post func(id int)) {
+	wrap := func(f func(int)) func(int) {
+		if f == nil {
+			return nil
+		}
+		return func(i int) {
+			if i != t.root {
+				f(t.label(i))
+			}
+		}
+	}
+	t.walk(t.root, wrap(pre), wrap(post))
+}
+
+// PreOrder returns the nodes of the tree in DFS preorder, so every node comes after its dominators
+func (t *DomTree) PreOrder() []int {
+	order := make([]int, 0, len(t.v.labels))
+	t.Visit(func(id int) {
+		order = append(order, id)
+	}, nil)
+	return order
+}
+
+// PostOrder returns the nodes of the tree in DFS postorder, so every node comes before its dominators
+func (t *DomTree) PostOrder() []int {
+	order := make([]int, 0, len(t.v.labels))
+	t.Visit(nil, func(id int) {
+		order = append(order, id)
+	})
+	return order
+}
+
+// Frontier returns the dominance frontier of the node: the nodes where its dominance ends, i.e. which it does not
+// strictly dominate but dominates a predecessor of
+func (t *DomTree) Frontier(id int) []int {
+	i, ok := t.lookup(id)
+	if !ok {
+		return []int{}
+	}
+	return t.v.labelsOf(t.frontiers[i])
+}
+
+// Frontiers returns the dominance frontier of every node in the tree
+func (t *DomTree) Frontiers() map[int][]int {
+	res := make(map[int][]int)
+	t.Visit(func(id int) {
+		res[id] = t.Frontier(id)
+	}, nil)
+	return res
+}
+
+// IteratedFrontier returns the iterated dominance frontier of the nodes, i.e. the limit of adding the frontiers of
+// all nodes found so far. These are the nodes where SSA form needs phi functions for a variable assigned in ids.
+func (t *DomTree) IteratedFrontier(ids []int) []int {
+	inResult := make([]bool, len(t.v.labels))
+	onWorklist := make([]bool, len(t.v.labels))
+	worklist := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if i, ok := t.lookup(id); ok && !onWorklist[i] {
+			onWorklist[i] = true
+			worklist = append(worklist, i)
+		}
+	}
+
+	result := make([]int, 0)
+	for len(worklist) > 0 {
+		i := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		for _, f := range t.frontiers[i] {
+			if inResult[f] {
+				continue
+			}
+			inResult[f] = true
+			result = append(result, f)
+			if !onWorklist[f] {
+				onWorklist[f] = true
+				worklist = append(worklist, f)
+			}
+		}
+	}
+	return t.v.labelsOf(result)
+}
+
+// dfsPostorder returns the nodes reachable from start in DFS postorder
+func dfsPostorder(start int, succs func(int) []int) []int {
+	order := make([]int, 0)
+	visited := map[int]bool{start: true}
+
+	type frame struct {
+		node, next int
+	}
+	stack := []frame{{start, 0}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		ss := succs(top.node)
+		if top.next < len(ss) {
+			s := ss[top.next]
+			top.next++
+			if !visited[s] {
+				visited[s] = true
+				stack = append(stack, frame{s, 0})
+			}
+			continue
+		}
+		order = append(order, top.node)
+		stack = stack[:len(stack)-1]
+	}
+	return order
+}
+
+func appendUnique(xs []int, x int) []int {
+	for _, y := range xs {
+		if y == x {
+			return xs
+		}
+	}
+	return append(xs, x)
+}
@@ -0,0 +1,154 @@
+package dataflowanalysis_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// sorted returns a sorted copy of xs, never nil
+func sorted(xs []int) []int {
+	res := append([]int{}, xs...)
+	sort.Ints(res)
+	return res
+}
+
+// Small graphs with known dominators, idom maps each reachable node to its immediate dominator, 0 for roots
+var domTests = []struct {
+	name        string
+	graph       string
+	idom        map[int]int
+	unreachable []int
+	frontiers   map[int][]int
+	exits       []int
+	ipdom       map[int]int // The immediate post-dominators, relative to exits
+}{
+	{
+		name:      "straight line",
+		graph:     "entry 1; 1 -> 2 -> 3",
+		idom:      map[int]int{1: 0, 2: 1, 3: 2},
+		frontiers: map[int][]int{1: {}, 2: {}, 3: {}},
+		exits:     []int{3},
+		ipdom:     map[int]int{1: 2, 2: 3, 3: 0},
+	},
+	{
+		name:      "diamond",
+		graph:     "entry 1; 1 -> 2 -> 4; 1 -T-> 3 -> 4",
+		idom:      map[int]int{1: 0, 2: 1, 3: 1, 4: 1},
+		frontiers: map[int][]int{1: {}, 2: {4}, 3: {4}, 4: {}},
+		exits:     []int{4},
+		ipdom:     map[int]int{1: 4, 2: 4, 3: 4, 4: 0},
+	},
+	{
+		name:      "while loop",
+		graph:     "entry 1; 1 -> 2 -> 3 -> 2; 2 -T-> 4",
+		idom:      map[int]int{1: 0, 2: 1, 3: 2, 4: 2},
+		frontiers: map[int][]int{1: {}, 2: {2}, 3: {2}, 4: {}},
+		exits:     []int{4},
+		ipdom:     map[int]int{1: 2, 2: 4, 3: 2, 4: 0},
+	},
+	{
+		name:      "if inside a loop",
+		graph:     "entry 1; 1 -> 2 -> 3 -> 5 -> 2; 3 -T-> 4 -> 5; 2 -T-> 6",
+		idom:      map[int]int{1: 0, 2: 1, 3: 2, 4: 3, 5: 3, 6: 2},
+		frontiers: map[int][]int{1: {}, 2: {2}, 3: {2}, 4: {5}, 5: {2}, 6: {}},
+		exits:     []int{6},
+		ipdom:     map[int]int{1: 2, 2: 6, 3: 5, 4: 5, 5: 2, 6: 0},
+	},
+	{
+		name:        "unreachable node",
+		graph:       "entry 1; 1 -> 2; 3 -> 2",
+		idom:        map[int]int{1: 0, 2: 1},
+		unreachable: []int{3},
+		frontiers:   map[int][]int{1: {}, 2: {}},
+		exits:       []int{2},
+		ipdom:       map[int]int{1: 2, 2: 0, 3: 2},
+	},
+	{
+		name:      "two entries",
+		graph:     "entry 1; entry 2; 1 -> 3; 2 -> 3 -> 4",
+		idom:      map[int]int{1: 0, 2: 0, 3: 0, 4: 3},
+		frontiers: map[int][]int{1: {3}, 2: {3}, 3: {}, 4: {}},
+		exits:     []int{4},
+		ipdom:     map[int]int{1: 3, 2: 3, 3: 4, 4: 0},
+	},
+}
+
+func checkIdoms(t *testing.T, kind string, tree *dfa.DomTree, want map[int]int) {
+	t.Helper()
+	for id, wantIdom := range want {
+		if !tree.Reachable(id) {
+			t.Errorf("%s: %d is not part of the tree", kind, id)
+			continue
+		}
+		idom, ok := tree.Idom(id)
+		if !ok {
+			idom = 0
+		}
+		if idom != wantIdom {
+			t.Errorf("%s: idom(%d) = %d, want %d", kind, id, idom, wantIdom)
+		}
+		if ok && !tree.StrictlyDominates(idom, id) {
+			t.Errorf("%s: %d does not strictly dominate %d", kind, idom, id)
+		}
+	}
+}
+
+func TestDominators(t *testing.T) {
+	for _, tt := range domTests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := dfa.MustParseGraph(tt.graph)
+			dom := dfa.Dominators(g.Entries, g.IDs(), g.Nodes())
+			checkIdoms(t, "dominators", dom, tt.idom)
+			for _, id := range tt.unreachable {
+				if dom.Reachable(id) {
+					t.Errorf("unreachable node %d is part of the tree", id)
+				}
+				if _, ok := dom.Idom(id); ok {
+					t.Errorf("unreachable node %d has an idom", id)
+				}
+			}
+			if got, want := len(dom.PreOrder()), len(tt.idom); got != want {
+				t.Errorf("the tree has %d nodes, want %d", got, want)
+			}
+
+			got := make(map[int][]int)
+			for id, f := range dom.Frontiers() {
+				got[id] = sorted(f)
+			}
+			if !reflect.DeepEqual(got, tt.frontiers) {
+				t.Errorf("Frontiers() = %v, want %v", got, tt.frontiers)
+			}
+		})
+	}
+}
+
+func TestPostDominators(t *testing.T) {
+	for _, tt := range domTests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := dfa.MustParseGraph(tt.graph)
+			checkIdoms(t, "post-dominators", dfa.PostDominators(tt.exits, g.IDs(), g.Nodes()), tt.ipdom)
+		})
+	}
+}
+
+func TestIteratedFrontier(t *testing.T) {
+	// 1 -> 2 -> 3 -> 5 -> 2 with 3 -T-> 4 -> 5: a definition in 4 needs phis at 5, and through 5 at the loop head 2
+	g := dfa.MustParseGraph("entry 1; 1 -> 2 -> 3 -> 5 -> 2; 3 -T-> 4 -> 5; 2 -T-> 6")
+	dom := dfa.Dominators(g.Entries, g.IDs(), g.Nodes())
+	tests := []struct {
+		defs []int
+		want []int
+	}{
+		{defs: []int{1}, want: []int{}},
+		{defs: []int{4}, want: []int{2, 5}},
+		{defs: []int{3}, want: []int{2}},
+		{defs: []int{1, 4, 6}, want: []int{2, 5}},
+	}
+	for _, tt := range tests {
+		if got := sorted(dom.IteratedFrontier(tt.defs)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("IteratedFrontier(%v) = %v, want %v", tt.defs, got, tt.want)
+		}
+	}
+}
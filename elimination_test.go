@@ -0,0 +1,140 @@
+package dataflowanalysis
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// renderRegion prints a region of the interval hierarchy, intervals in brackets followed by * if cyclic, and the
+// virtual entry as v
+func renderRegion(r *region, labels []int) string {
+	if r.leaf >= 0 {
+		if r.leaf >= len(labels) {
+			return "v"
+		}
+		return strconv.Itoa(labels[r.leaf])
+	}
+	strs := make([]string, len(r.children))
+	for i, c := range r.children {
+		strs[i] = renderRegion(c, labels)
+	}
+	s := "[" + strings.Join(strs, " ") + "]"
+	if r.cyclic {
+		s += "*"
+	}
+	return s
+}
+
+func TestIntervalHierarchy(t *testing.T) {
+	tests := []struct {
+		name      string
+		graph     string
+		hierarchy string // Empty if the graph is irreducible
+	}{
+		{name: "straight line", graph: "entry 1; 1 -> 2 -> 3", hierarchy: "[v 1 2 3]"},
+		{name: "diamond", graph: "entry 1; 1 -> 2 -> 4; 1 -T-> 3 -> 4", hierarchy: "[v 1 2 3 4]"},
+		{name: "self loop", graph: "entry 1; 1 -> 1 -> 2", hierarchy: "[[v] [1 2]*]"},
+		{name: "while loop", graph: "entry 1; 1 -> 2 -> 3 -> 2; 2 -T-> 4", hierarchy: "[[v 1] [2 3 4]*]"},
+		{
+			// 3 has a predecessor outside of the interval of 2, so the inner loop becomes an interval of its own
+			name:      "nested loops",
+			graph:     "entry 1; 1 -> 2 -> 3 -> 4 -> 3; 4 -T-> 5 -> 2; 2 -T-> 6",
+			hierarchy: "[[[v 1]] [[2 6] [3 4 5]*]*]",
+		},
+		{
+			// The virtual entry leads to the unreachable loop
+			name:      "unreachable loop",
+			graph:     "entry 1; 1 -> 2; 3 -> 4 -> 3 -> 2",
+			hierarchy: "[[v 1] [3 4]* [2]]",
+		},
+		{name: "irreducible cycle", graph: "entry 1; 1 -> 2 -> 3 -> 2; 1 -T-> 3; 3 -T-> 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := MustParseGraph(tt.graph)
+			top, labels, reducible := intervalHierarchy(g.Entries, g.IDs(), g.Nodes())
+			if reducible != (tt.hierarchy != "") {
+				t.Fatalf("reducible = %v", reducible)
+			}
+			if Reducible(g.Entries, g.IDs(), g.Nodes()) != reducible {
+				t.Errorf("Reducible disagrees with intervalHierarchy")
+			}
+			if !reducible {
+				return
+			}
+			if got := renderRegion(top, labels); got != tt.hierarchy {
+				t.Errorf("hierarchy = %s, want %s", got, tt.hierarchy)
+			}
+		})
+	}
+}
+
+// A labelSet is the set of labels of the nodes on some path from an entry to a node
+type labelSet uint64
+
+func (s labelSet) Equals(f Fact) bool {
+	return s == f.(labelSet)
+}
+
+func (s labelSet) String() string {
+	strs := make([]string, 0)
+	for l := 0; l < 64; l++ {
+		if s&(1<<l) != 0 {
+			strs = append(strs, strconv.Itoa(l))
+		}
+	}
+	return "{" + strings.Join(strs, " ") + "}"
+}
+
+func labelSetUnion(a, b Fact) Fact {
+	return a.(labelSet) | b.(labelSet)
+}
+
+func labelSetFlow(f Fact, n Node) (Fact, Fact) {
+	out := f.(labelSet) | 1<<n.Label()
+	return out, out
+}
+
+func TestRunForwardElimination(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph string
+		in    map[int]labelSet
+	}{
+		{
+			name:  "nested loops",
+			graph: "entry 1; 1 -> 2 -> 3 -> 4 -> 3; 4 -T-> 5 -> 2; 2 -T-> 6",
+			in:    map[int]labelSet{1: 0, 2: 0x3e, 3: 0x3e, 4: 0x3e, 5: 0x3e, 6: 0x3e},
+		},
+		{
+			// Unreachable nodes are solved from initialFlow
+			name:  "unreachable loop",
+			graph: "entry 1; 1 -> 2; 3 -> 4 -> 3 -> 2",
+			in:    map[int]labelSet{1: 0, 2: 0x1a, 3: 0x18, 4: 0x18},
+		},
+		{
+			// Irreducible graphs fall back to RunForward
+			name:  "irreducible cycle",
+			graph: "entry 1; 1 -> 2 -> 3 -> 2; 1 -T-> 3; 3 -T-> 4",
+			in:    map[int]labelSet{1: 0, 2: 0xe, 3: 0xe, 4: 0xe},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := MustParseGraph(tt.graph)
+			in, _, _ := RunForwardElimination(g.Entries, g.IDs(), g.Nodes(), labelSetUnion, labelSetFlow, labelSet(0),
+				labelSet(0))
+			want := make(map[int]Fact, len(tt.in))
+			for id, s := range tt.in {
+				want[id] = s
+			}
+			if !reflect.DeepEqual(in, want) {
+				t.Errorf("in = %v, want %v", in, want)
+			}
+		})
+	}
+}
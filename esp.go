@@ -0,0 +1,197 @@
+package dataflowanalysis
+
+import (
+	"sort"
+	"strings"
+)
+
+// A Predicate is a simple symbolic path predicate: the conjunction of the branch conditions known to be true or false
+// on all traces, by the text of the condition
+type Predicate map[string]bool
+
+func (p Predicate) Equals(other Fact) bool {
+	o := other.(Predicate)
+	if len(p) != len(o) {
+		return false
+	}
+	for cond, v := range p {
+		if ov, ok := o[cond]; !ok || ov != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (p Predicate) String() string {
+	if len(p) == 0 {
+		return "{ true }"
+	}
+	conds := make([]string, 0, len(p))
+	for cond, v := range p {
+		if v {
+			conds = append(conds, cond)
+		} else {
+			conds = append(conds, "!("+cond+")")
+		}
+	}
+	sort.Strings(conds)
+	return "{ " + strings.Join(conds, " && ") + " }"
+}
+
+// Meet returns the conditions on which both predicates agree, i.e. what is known on the traces of both
+func (p Predicate) Meet(other Predicate) Predicate {
+	res := make(Predicate)
+	for cond, v := range p {
+		if ov, ok := other[cond]; ok && ov == v {
+			res[cond] = v
+		}
+	}
+	return res
+}
+
+func (p Predicate) with(cond string, v bool) Predicate {
+	res := make(Predicate, len(p)+1)
+	for c, cv := range p {
+		res[c] = cv
+	}
+	res[cond] = v
+	return res
+}
+
+// A Property is a finite-state property of the traces, e.g. the typestate of a resource. Step returns the state after
+// the node, given the state before it.
+type Property struct {
+	Initial string // The state at the entries
+	Step    func(state string, n Node) string
+}
+
+// PathConditions tells ESP which conditions branches test and when they stop holding
+type PathConditions struct {
+	Cond  func(n Node) (cond string, ok bool) // The condition of a branch node, ok is false if it is not tracked
+	Kills func(cond string, n Node) bool      // Whether the node may change the value of the condition
+}
+
+// killsAll is the Kills of PathConditions that leave it nil: any node other than a branch may change any condition
+func killsAll(_ string, n Node) bool {
+	return len(n.SuccsTaken()) == 0
+}
+
+// An ESPState is the fact of one property state of an ESP analysis: the path predicate and the data fact that hold on
+// all traces in that state
+type ESPState struct {
+	Predicate Predicate
+	Data      Fact // nil if ESP tracks no data
+}
+
+func (s ESPState) Equals(other Fact) bool {
+	o := other.(ESPState)
+	if !s.Predicate.Equals(o.Predicate) {
+		return false
+	}
+	if s.Data == nil || o.Data == nil {
+		return s.Data == nil && o.Data == nil
+	}
+	return s.Data.Equals(o.Data)
+}
+
+func (s ESPState) String() string {
+	if s.Data == nil {
+		return s.Predicate.String()
+	}
+	return s.Predicate.String() + " " + s.Data.String()
+}
+
+// ESP lifts data to an analysis in the style of ESP (Das, Lerner and Seigle): facts are DisjunctiveFacts whose keys are
+// the states of property and whose facts are ESPStates, so traces are merged only if they agree on the property
+// state. Within a state, the path predicate records the outcomes of the tracked branch conditions, and a branch whose
+// condition the predicate decides only flows along the feasible edge, so correlated branches later in the program do
+// not mix up property states.
+//
+// data may be the zero Analysis to track the property and predicates only. If its flow function returns nil for an
+// edge, the edge is infeasible for the state as well. With the zero PathConditions, no conditions are tracked and
+// the states are merely separated. If Cond is set but Kills is nil, every node that is not a branch kills every
+// condition, so the predicates only relate branches with no other node in between.
+func ESP(property Property, conds PathConditions, data Analysis) Analysis {
+	if conds.Cond != nil && conds.Kills == nil {
+		conds.Kills = killsAll
+	}
+	inner := Analysis{
+		Merge: func(f1, f2 Fact) Fact {
+			s1, s2 := f1.(ESPState), f2.(ESPState)
+			merged := ESPState{Predicate: s1.Predicate.Meet(s2.Predicate)}
+			if data.Merge != nil {
+				merged.Data = data.Merge(s1.Data, s2.Data)
+			}
+			return merged
+		},
+		Flow: func(f Fact, n Node) (Fact, Fact) {
+			s := f.(ESPState)
+			pred := s.Predicate
+			if conds.Kills != nil {
+				pred = killed(pred, n, conds.Kills)
+			}
+
+			var dataNotTaken, dataTaken Fact
+			if data.Flow != nil {
+				dataNotTaken, dataTaken = data.Flow(s.Data, n)
+			}
+			feasible := func(out Fact) bool {
+				return data.Flow == nil || out != nil
+			}
+
+			var outNotTaken, outTaken Fact
+			if len(n.SuccsTaken()) == 0 {
+				if feasible(dataNotTaken) {
+					outNotTaken = ESPState{Predicate: pred, Data: dataNotTaken}
+				}
+				return outNotTaken, nil
+			}
+
+			predNotTaken, predTaken := pred, pred
+			if conds.Cond != nil {
+				if cond, ok := conds.Cond(n); ok {
+					// A condition the predicate decides makes the other edge infeasible
+					if v, known := pred[cond]; !known {
+						predNotTaken, predTaken = pred.with(cond, false), pred.with(cond, true)
+					} else if v {
+						predNotTaken = nil
+					} else {
+						predTaken = nil
+					}
+				}
+			}
+			if predNotTaken != nil && feasible(dataNotTaken) {
+				outNotTaken = ESPState{Predicate: predNotTaken, Data: dataNotTaken}
+			}
+			if predTaken != nil && feasible(dataTaken) {
+				outTaken = ESPState{Predicate: predTaken, Data: dataTaken}
+			}
+			return outNotTaken, outTaken
+		},
+		Entry: ESPState{Predicate: Predicate{}, Data: data.Entry},
+	}
+
+	step := func(state string, _ Fact, n Node, _ bool) string {
+		return property.Step(state, n)
+	}
+	esp := Disjunctive(inner, step, int(^uint(0)>>1), CollapseAll)
+	esp.Entry = DisjunctiveFact{Partitions: []Partition{{Key: property.Initial, Fact: inner.Entry}}}
+	return esp
+}
+
+// killed returns the predicate without the conditions the node may change
+func killed(pred Predicate, n Node, kills func(string, Node) bool) Predicate {
+	var res Predicate
+	for cond := range pred {
+		if kills(cond, n) {
+			if res == nil {
+				res = pred.with(cond, false)
+			}
+			delete(res, cond)
+		}
+	}
+	if res == nil {
+		return pred
+	}
+	return res
+}
@@ -0,0 +1,137 @@
+package dataflowanalysis_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The statements of the ESP tests: "open" and "close" change the state of a file, "if p" branches on p, "p = ..."
+// assigns p, anything else does nothing
+
+var fileProperty = dfa.Property{
+	Initial: "closed",
+	Step: func(state string, n dfa.Node) string {
+		switch n.Get() {
+		case "open":
+			return "open"
+		case "close":
+			return "closed"
+		}
+		return state
+	},
+}
+
+func branchCond(n dfa.Node) (string, bool) {
+	stmt, _ := n.Get().(string)
+	if strings.HasPrefix(stmt, "if ") {
+		return strings.TrimPrefix(stmt, "if "), true
+	}
+	return "", false
+}
+
+func assigns(cond string, n dfa.Node) bool {
+	stmt, _ := n.Get().(string)
+	return strings.HasPrefix(stmt, cond+" = ")
+}
+
+func TestESP(t *testing.T) {
+	// The file is opened if p holds, and closed if p still holds after the statement between
+	graph := func(between string) string {
+		return `entry 1; 1 -T-> 2 -> 3 -> 6; 1 -> 3; 6 -T-> 4 -> 5; 6 -> 5
+			1 "if p"; 2 "open"; 3 "` + between + `"; 4 "close"; 6 "if p"`
+	}
+
+	tests := []struct {
+		name  string
+		graph string
+		conds dfa.PathConditions
+		keys  []string // The property states reaching 4
+		pred  dfa.Predicate
+	}{
+		{
+			// Without conditions, the closed state of the not-taken branch reaches the close
+			name:  "no conditions",
+			graph: graph("skip"),
+			keys:  []string{"closed", "open"},
+			pred:  dfa.Predicate{},
+		},
+		{
+			// p is decided in both states, so the closed state cannot take the second branch
+			name:  "decided condition",
+			graph: graph("skip"),
+			conds: dfa.PathConditions{Cond: branchCond, Kills: assigns},
+			keys:  []string{"open"},
+			pred:  dfa.Predicate{"p": true},
+		},
+		{
+			name:  "killed condition",
+			graph: graph("p = q"),
+			conds: dfa.PathConditions{Cond: branchCond, Kills: assigns},
+			keys:  []string{"closed", "open"},
+			pred:  dfa.Predicate{"p": true},
+		},
+		{
+			// Without Kills, every node other than a branch kills every condition
+			name:  "nil Kills",
+			graph: graph("skip"),
+			conds: dfa.PathConditions{Cond: branchCond},
+			keys:  []string{"closed", "open"},
+			pred:  dfa.Predicate{"p": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := dfa.MustParseGraph(tt.graph)
+			in, _, _ := dfa.ESP(fileProperty, tt.conds, dfa.Analysis{}).Run(g.Entries, g.IDs(), g.Nodes())
+
+			keys := make([]string, 0)
+			for _, p := range in[4].(dfa.DisjunctiveFact).Partitions {
+				keys = append(keys, p.Key)
+				if pred := p.Fact.(dfa.ESPState).Predicate; !pred.Equals(tt.pred) {
+					t.Errorf("state %s reaches 4 where %v, want %v", p.Key, pred, tt.pred)
+				}
+			}
+			if !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("states %v reach 4, want %v", keys, tt.keys)
+			}
+		})
+	}
+}
+
+func TestESPMergesAgreeingStates(t *testing.T) {
+	// Both branches keep the file closed, so their traces merge and p is unknown after the join, while the open
+	// state of the second diamond stays apart
+	g := dfa.MustParseGraph(`entry 1; 1 -T-> 2 -> 3; 1 -> 3; 3 -T-> 4 -> 5; 3 -> 5
+		1 "if p"; 2 "skip"; 3 "if q"; 4 "open"`)
+	conds := dfa.PathConditions{Cond: branchCond, Kills: assigns}
+	in, _, _ := dfa.ESP(fileProperty, conds, visited).Run(g.Entries, g.IDs(), g.Nodes())
+
+	want := map[int]dfa.DisjunctiveFact{
+		3: {Partitions: []dfa.Partition{
+			{Key: "closed", Fact: dfa.ESPState{Predicate: dfa.Predicate{}, Data: maskOf(1, 2)}},
+		}},
+		5: {Partitions: []dfa.Partition{
+			{Key: "closed", Fact: dfa.ESPState{Predicate: dfa.Predicate{"q": false}, Data: maskOf(1, 2, 3)}},
+			{Key: "open", Fact: dfa.ESPState{Predicate: dfa.Predicate{"q": true}, Data: maskOf(1, 2, 3, 4)}},
+		}},
+	}
+	for id, w := range want {
+		if !in[id].Equals(w) {
+			t.Errorf("in(%d) = %v, want %v", id, in[id], w)
+		}
+	}
+}
+
+func TestPredicateMeet(t *testing.T) {
+	p := dfa.Predicate{"a": true, "b": false, "c": true}
+	q := dfa.Predicate{"a": true, "b": true, "d": false}
+	if got, want := p.Meet(q), (dfa.Predicate{"a": true}); !got.Equals(want) {
+		t.Errorf("Meet = %v, want %v", got, want)
+	}
+	if got := p.Meet(dfa.Predicate{}); !got.Equals(dfa.Predicate{}) {
+		t.Errorf("Meet with true = %v", got)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/stringlang/ast"
+	"sort"
+	"strings"
+)
+
+type Emptiness int
+
+const (
+	Empty Emptiness = iota
+	NonEmpty
+	MaybeEmpty
+)
+
+func (e Emptiness) String() string {
+	switch e {
+	case Empty:
+		return "empty"
+	case NonEmpty:
+		return "non-empty"
+	}
+	return "maybe-empty"
+}
+
+func (e Emptiness) Join(other Emptiness) Emptiness {
+	if e == other {
+		return e
+	}
+	return MaybeEmpty
+}
+
+/*
+	The lattice of Var -> Emptiness
+	Like AbstractMap, the empty map models unreachability. Missing variables are uninitialized, i.e. Empty.
+*/
+
+type EmptinessMap map[string]Emptiness
+
+func (em EmptinessMap) IsBottom() bool {
+	return len(em) == 0
+}
+
+func (em EmptinessMap) Get(variable string) Emptiness {
+	return em[variable] // Empty if missing
+}
+
+func (em EmptinessMap) Join(other EmptinessMap) EmptinessMap {
+	if em.IsBottom() {
+		return other.copy()
+	}
+	if other.IsBottom() {
+		return em.copy()
+	}
+
+	res := make(EmptinessMap)
+	for k, v := range other {
+		res[k] = em.Get(k).Join(v)
+	}
+	for k, v := range em {
+		res[k] = other.Get(k).Join(v)
+	}
+	return res
+}
+
+func (em EmptinessMap) Equals(otherF dfa.Fact) bool {
+	other := otherF.(EmptinessMap)
+	if em.IsBottom() || other.IsBottom() {
+		return em.IsBottom() && other.IsBottom()
+	}
+	for k, v := range em {
+		if other.Get(k) != v {
+			return false
+		}
+	}
+	for k, v := range other {
+		if em.Get(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (em EmptinessMap) String() string {
+	if em.IsBottom() {
+		return "{ <BOTTOM> }"
+	}
+
+	variables := make([]string, 0, len(em))
+	for k := range em {
+		variables = append(variables, k)
+	}
+	sort.Strings(variables)
+
+	mappings := make([]string, 0, len(em))
+	for _, variable := range variables {
+		mappings = append(mappings, variable+"="+em[variable].String())
+	}
+	return "{ " + strings.Join(mappings, ", ") + " }"
+}
+
+func (em EmptinessMap) copy() EmptinessMap {
+	em2 := make(EmptinessMap, len(em))
+	for k, v := range em {
+		em2[k] = v
+	}
+	return em2
+}
+
+// refine returns em where the variable has the emptiness e, or unreachable if it cannot have it
+func (em EmptinessMap) refine(variable string, e Emptiness) EmptinessMap {
+	if cur := em.Get(variable); cur != MaybeEmpty && cur != e {
+		return EmptinessMap{}
+	}
+	res := em.copy()
+	res[variable] = e
+	return res
+}
+
+func emptinessOf(em EmptinessMap, expr ast.Expr) Emptiness {
+	switch val := expr.(type) {
+	case ast.Val:
+		if val == "" {
+			return Empty
+		}
+		return NonEmpty
+	case ast.Var:
+		return em.Get(string(val))
+	case ast.Concat:
+		a, b := emptinessOf(em, val.A), emptinessOf(em, val.B)
+		if a == NonEmpty || b == NonEmpty {
+			return NonEmpty
+		}
+		if a == Empty && b == Empty {
+			return Empty
+		}
+	}
+	return MaybeEmpty
+}
+
+// emptinessTest returns the variable a condition compares to "", and whether it tests for emptiness
+func emptinessTest(stmt dfa.Stmt) (variable string, empty bool, ok bool) {
+	var a, b ast.Expr
+	switch val := stmt.(type) {
+	case ast.Equals:
+		a, b, empty = val.A, val.B, true
+	case ast.NotEquals:
+		a, b, empty = val.A, val.B, false
+	default:
+		return "", false, false
+	}
+	if _, isVar := b.(ast.Var); isVar {
+		a, b = b, a
+	}
+	v, isVar := a.(ast.Var)
+	lit, isVal := b.(ast.Val)
+	if !isVar || !isVal || lit != "" {
+		return "", false, false
+	}
+	return string(v), empty, true
+}
+
+// emptinessAnalysis tracks which variables hold empty strings, refining them at branches that compare a variable
+// to ""
+func emptinessAnalysis(vars []string) dfa.Analysis {
+	entry := make(EmptinessMap)
+	for _, variable := range vars {
+		entry[variable] = Empty // StringLang semantics, uninitialized variables are ""
+	}
+
+	return dfa.Analysis{
+		Merge: func(f1, f2 dfa.Fact) dfa.Fact {
+			return f1.(EmptinessMap).Join(f2.(EmptinessMap))
+		},
+		Flow: func(f dfa.Fact, node dfa.Node) (dfa.Fact, dfa.Fact) {
+			em := f.(EmptinessMap)
+			if em.IsBottom() {
+				return em, em
+			}
+			if assn, ok := node.Get().(ast.Assn); ok {
+				res := em.copy()
+				res[string(assn.V)] = emptinessOf(em, assn.E)
+				return res, res
+			}
+			if variable, empty, ok := emptinessTest(node.Get()); ok {
+				if empty {
+					return em.refine(variable, NonEmpty), em.refine(variable, Empty)
+				}
+				return em.refine(variable, Empty), em.refine(variable, NonEmpty)
+			}
+			return em, em
+		},
+		Initial: make(EmptinessMap),
+		Entry:   entry,
+	}
+}
+
+// constantAnalysis is the constant propagation of compareToMOP as a path-sensitive analysis, except that
+// uninitialized variables are the constant "" like in emptinessAnalysis, so that the reduction is sound: with
+// Bottom, a path not assigning a variable would vanish in the join with a path assigning it a constant
+func constantAnalysis(vars []string) dfa.Analysis {
+	entry := make(AbstractMap)
+	for _, variable := range vars {
+		entry[variable] = Constant("")
+	}
+
+	return dfa.Analysis{
+		Merge: func(f1, f2 dfa.Fact) dfa.Fact {
+			return f1.(AbstractMap).Join(f2.(AbstractMap))
+		},
+		Flow: func(f dfa.Fact, node dfa.Node) (dfa.Fact, dfa.Fact) {
+			am := f.(AbstractMap).copy()
+			if assn, ok := node.Get().(ast.Assn); ok && !am.IsBottom() {
+				am[string(assn.V)] = transform(am, assn.E)
+			}
+			return am, am
+		},
+		Initial: make(AbstractMap),
+		Entry:   entry,
+	}
+}
+
+// reduceConstantEmptiness lets constants decide emptiness, and emptiness decide the constant ""
+func reduceConstantEmptiness(p dfa.ProductFact, _ dfa.Node) dfa.ProductFact {
+	am, em := p[0].(AbstractMap), p[1].(EmptinessMap)
+	if am.IsBottom() || em.IsBottom() {
+		return dfa.ProductFact{make(AbstractMap), make(EmptinessMap)}
+	}
+
+	am, em = am.copy(), em.copy()
+	for variable, s := range am {
+		switch {
+		case s.IsConstant() && s.Constant == "":
+			em[variable] = Empty
+		case s.IsConstant():
+			em[variable] = NonEmpty
+		case s.IsTop() && em.Get(variable) == Empty:
+			am[variable] = Constant("")
+		}
+	}
+	return dfa.ProductFact{am, em}
+}
+
+// reducedProduct runs constant propagation and the emptiness analysis alone and as a reduced product, returning
+// the in facts of each
+func reducedProduct(entryIds []int, ids []int, idToNode map[int]dfa.Node, vars []string) (constants, emptiness map[int]dfa.Fact, product map[int]dfa.Fact) {
+	constants, _, _ = constantAnalysis(vars).Run(entryIds, ids, idToNode)
+	emptiness, _, _ = emptinessAnalysis(vars).Run(entryIds, ids, idToNode)
+	product, _, _ = dfa.Product(reduceConstantEmptiness, constantAnalysis(vars), emptinessAnalysis(vars)).
+		Run(entryIds, ids, idToNode)
+	return constants, emptiness, product
+}
+
+// refinedVars lists the variables whose facts the reduced product refined, as "var: alone -> reduced"
+func refinedVars(alone, reduced dfa.Fact) []string {
+	refined := make([]string, 0)
+	switch alone := alone.(type) {
+	case AbstractMap:
+		for _, variable := range sortedVars(alone) {
+			if a, r := alone.Get(variable), reduced.(AbstractMap).Get(variable); !a.Equals(r) {
+				refined = append(refined, variable+": "+a.String()+" -> "+r.String())
+			}
+		}
+	case EmptinessMap:
+		for _, variable := range sortedVars(alone) {
+			if a, r := alone.Get(variable), reduced.(EmptinessMap).Get(variable); a != r {
+				refined = append(refined, variable+": "+a.String()+" -> "+r.String())
+			}
+		}
+	}
+	return refined
+}
+
+func sortedVars(m interface{}) []string {
+	variables := make([]string, 0)
+	switch m := m.(type) {
+	case AbstractMap:
+		for k := range m {
+			variables = append(variables, k)
+		}
+	case EmptinessMap:
+		for k := range m {
+			variables = append(variables, k)
+		}
+	}
+	sort.Strings(variables)
+	return variables
+}
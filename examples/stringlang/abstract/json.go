@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	dfa "github.com/skius/dataflowanalysis"
+)
+
+func init() {
+	// AbstractMap and AbsString only have exported fields, so encoding/json round-trips them
+	dfa.RegisterFact("stringlang.AbstractMap", AbstractMap{})
+}
+
+// roundTrip encodes the result as JSON and decodes it again, returning whether all facts survived and the size
+func roundTrip(res *dfa.Result) (bool, int) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		panic(err)
+	}
+	var decoded dfa.Result
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		panic(err)
+	}
+	for id, f := range res.In {
+		if !f.Equals(decoded.In[id]) {
+			return false, len(data)
+		}
+	}
+	return true, len(data)
+}
@@ -1,5 +1,7 @@
 package main
 
+import dfa "github.com/skius/dataflowanalysis"
+
 type AbsStringType int
 
 const (
@@ -57,7 +59,7 @@ func (s *AbsString) Meet(other *AbsString) *AbsString {
 		return s.copy()
 	}
 	if s.IsBottom() || other.IsBottom() {
-		return s.copy()
+		return Bottom()
 	}
 	if s.IsConstant() && other.IsConstant() && s.Constant == other.Constant {
 		return s.copy()
@@ -72,7 +74,7 @@ func (s *AbsString) Join(other *AbsString) *AbsString {
 		return s.copy()
 	}
 	if s.IsTop() || other.IsTop() {
-		return s.copy()
+		return Top()
 	}
 	if s.IsConstant() && other.IsConstant() && s.Constant == other.Constant {
 		return s.copy()
@@ -80,7 +82,8 @@ func (s *AbsString) Join(other *AbsString) *AbsString {
 	return Top()
 }
 
-func (s *AbsString) Equals(other *AbsString) bool {
+func (s *AbsString) Equals(otherF dfa.Fact) bool {
+	other := otherF.(*AbsString)
 	if s.IsBottom() && other.IsBottom() {
 		return true
 	}
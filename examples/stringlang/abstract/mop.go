@@ -0,0 +1,33 @@
+package main
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/stringlang/ast"
+)
+
+// compareToMOP runs a path-insensitive constant propagation with RunForwardPI and RunMOPPI, showing where the
+// fixpoint loses precision because the constant lattice is not distributive
+func compareToMOP(entryIds []int, ids []int, idToNode map[int]dfa.NodePI, vars []string) (mfp, mop map[int]dfa.Fact, precision map[int]dfa.Precision) {
+	merge := func(am1F, am2F dfa.Fact) dfa.Fact {
+		return am1F.(AbstractMap).Join(am2F.(AbstractMap))
+	}
+
+	flow := func(amF dfa.Fact, node dfa.NodePI) dfa.Fact {
+		am := amF.(AbstractMap).copy()
+		if assn, ok := node.Get().(ast.Assn); ok && !am.IsBottom() {
+			am[string(assn.V)] = transform(am, assn.E)
+		}
+		return am
+	}
+
+	// The empty map is unreachable code, the entry has every variable uninitialized
+	initial := make(AbstractMap)
+	entry := make(AbstractMap)
+	for _, variable := range vars {
+		entry[variable] = Bottom()
+	}
+
+	mfp, _ = dfa.RunForwardPI(entryIds, ids, idToNode, merge, flow, initial, entry)
+	mop = dfa.RunMOPPI(entryIds, ids, idToNode, merge, flow, initial, entry, 1)
+	return mfp, mop, dfa.ComparePrecision(ids, mfp, mop, merge)
+}
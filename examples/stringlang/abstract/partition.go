@@ -0,0 +1,18 @@
+package main
+
+import dfa "github.com/skius/dataflowanalysis"
+
+// partitionedConstants runs constant propagation alone and partitioned by the outcomes of the last two branches, with
+// at most four partitions per node, returning the in facts of each, the latter merged over all partitions
+func partitionedConstants(entryIds []int, ids []int, idToNode map[int]dfa.Node, vars []string) (plain, partitioned map[int]dfa.Fact) {
+	constants := constantAnalysis(vars)
+	plain, _, _ = constants.Run(entryIds, ids, idToNode)
+
+	disjunctive, _, _ := dfa.Disjunctive(constants, dfa.BranchHistory(2), 4, dfa.CollapseOldest(2)).
+		Run(entryIds, ids, idToNode)
+	partitioned = make(map[int]dfa.Fact, len(ids))
+	for _, id := range ids {
+		partitioned[id] = disjunctive[id].(dfa.DisjunctiveFact).Merged(constants.Merge, constants.Initial)
+	}
+	return plain, partitioned
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/ssa"
+	"github.com/skius/stringlang/ast"
+	"strings"
+)
+
+// evaluator implements ssa.Evaluator for stringlang using the abstract string transformers
+type evaluator struct{}
+
+// toAbstract converts the SCCP values of the operands, ok is false if one of them is still Undefined
+func toAbstract(uses map[string]ssa.Lattice) (am AbstractMap, ok bool) {
+	am = make(AbstractMap, len(uses))
+	for v, l := range uses {
+		switch l.Kind {
+		case ssa.Undefined:
+			return nil, false
+		case ssa.Constant:
+			am[v] = Constant(l.Const.(string))
+		default:
+			am[v] = Top()
+		}
+	}
+	return am, true
+}
+
+func fromAbstract(s *AbsString) ssa.Lattice {
+	if s.IsTop() {
+		return ssa.Lattice{Kind: ssa.Overdefined}
+	}
+	return ssa.ConstantValue(s.forceEval())
+}
+
+func (evaluator) Eval(stmt dfa.Stmt, uses map[string]ssa.Lattice) map[string]ssa.Lattice {
+	assn := stmt.(ast.Assn)
+	am, ok := toAbstract(uses)
+	if !ok {
+		// Wait until all operands have a value
+		return map[string]ssa.Lattice{string(assn.V): {Kind: ssa.Undefined}}
+	}
+	return map[string]ssa.Lattice{string(assn.V): fromAbstract(transform(am, assn.E))}
+}
+
+func (evaluator) Branch(stmt dfa.Stmt, uses map[string]ssa.Lattice) (notTaken, taken bool) {
+	am, ok := toAbstract(uses)
+	if !ok {
+		return false, false
+	}
+
+	switch val := stmt.(type) {
+	case ast.Equals:
+		left := transform(am, val.A)
+		right := transform(am, val.B)
+		if left.IsConstant() && right.IsConstant() {
+			equal := left.Constant == right.Constant
+			return !equal, equal
+		}
+		return true, true
+	case ast.NotEquals:
+		left := transform(am, val.A)
+		right := transform(am, val.B)
+		if left.IsConstant() && right.IsConstant() {
+			equal := left.Constant == right.Constant
+			return equal, !equal
+		}
+		return true, true
+	}
+
+	switch isTruthyVal(transform(am, stmt.(ast.Expr))) {
+	case 1:
+		return false, true
+	case -1:
+		return true, false
+	}
+	return true, true
+}
+
+func (evaluator) Entry(variable string) ssa.Lattice {
+	return ssa.ConstantValue("") // StringLang semantics, uninitialized variables are ""
+}
+
+// sccpValues formats the values of the versions the node uses and defines, or marks it unreachable
+func sccpValues(s *ssa.SSA, res *ssa.SCCPResult, label int) string {
+	if !res.Executable(label) {
+		return "{ <UNREACHABLE> }"
+	}
+
+	stmt := s.Stmt(label)
+	values := make([]ssa.Value, 0, len(stmt.Phis)+len(stmt.Uses)+len(stmt.Defs))
+	for _, p := range stmt.Phis {
+		values = append(values, p.Dest)
+	}
+	values = append(values, stmt.Uses...)
+	values = append(values, stmt.Defs...)
+
+	mappings := make([]string, 0, len(values))
+	seen := make(map[ssa.Value]bool, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			mappings = append(mappings, v.String()+"="+res.Value(v).String())
+		}
+	}
+	return "{ " + strings.Join(mappings, ", ") + " }"
+}
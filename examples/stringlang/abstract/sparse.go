@@ -0,0 +1,32 @@
+package main
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/analyses"
+	"github.com/skius/dataflowanalysis/examples/stringlang/cfgadapter"
+	"github.com/skius/stringlang/ast"
+)
+
+// runSparse propagates the constant of each assignment only to the nodes using it, instead of flowing an AbstractMap
+// of all variables through every node
+func runSparse(entryIds []int, ids []int, idToNode map[int]dfa.NodePI, du cfgadapter.DefUse) (*analyses.DefUseChains, *analyses.SparseFacts) {
+	chains := analyses.BuildDefUseChains(entryIds, ids, idToNode, du)
+
+	merge := func(s1F, s2F dfa.Fact) dfa.Fact {
+		return s1F.(*AbsString).Join(s2F.(*AbsString))
+	}
+
+	eval := func(label int, operands map[string]dfa.Fact) map[string]dfa.Fact {
+		assn := du[label].(ast.Assn)
+
+		// The operands are exactly the variables transform looks up
+		am := make(AbstractMap, len(operands))
+		for k, v := range operands {
+			am[k] = v.(*AbsString)
+		}
+		return map[string]dfa.Fact{string(assn.V): transform(am, assn.E)}
+	}
+
+	// Uninitialized variables are Bottom, i.e. "" in stringlang semantics
+	return chains, analyses.RunSparse(chains, merge, eval, Bottom(), Bottom())
+}
@@ -0,0 +1,76 @@
+// Package cfgadapter adapts the CFGs of stringlang programs to the node interfaces of dataflowanalysis, it is shared
+// by the stringlang examples and the commands.
+package cfgadapter
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/analyses"
+	"github.com/skius/stringlang/ast"
+	"github.com/skius/stringlang/cfg"
+	"github.com/skius/stringlang/optimizer"
+)
+
+// A Graph is the CFG of the top-level code of a stringlang program
+type Graph struct {
+	CFG     *dfa.Graph         // The CFG, whose statements are the ast.Exprs of the program
+	Program ast.Program        // The normalized program the CFG was built from
+	Entry   int                // The label of the entry node
+	IDs     []int              // The labels of all nodes, sorted
+	Nodes   map[int]dfa.Node   // The nodes, by label
+	NodesPI map[int]dfa.NodePI // The same nodes, for the path-insensitive solvers
+}
+
+// New normalizes the program and builds the CFG of its top-level code
+func New(prog ast.Program) *Graph {
+	prog = optimizer.Normalize(prog)
+	graph, _ := cfg.New(prog)
+
+	c := dfa.NewGraph()
+	graph.Visit(func(node *cfg.Node) {
+		c.AddNode(node.Label, node.Expr)
+		if node.SuccNotTaken != nil {
+			c.AddEdge(node.Label, node.SuccNotTaken.Label, false)
+		}
+		if node.SuccTaken != nil {
+			c.AddEdge(node.Label, node.SuccTaken.Label, true)
+		}
+	})
+	c.SetEntry(graph.Entry.Label)
+
+	return &Graph{
+		CFG:     c,
+		Program: prog,
+		Entry:   graph.Entry.Label,
+		IDs:     c.IDs(),
+		Nodes:   c.Nodes(),
+		NodesPI: c.NodesPI(),
+	}
+}
+
+// Expr returns the statement of the node
+func (g *Graph) Expr(label int) ast.Expr {
+	return g.CFG.Node(label).Get().(ast.Expr)
+}
+
+// DefUse returns the variables each node assigns and reads
+func (g *Graph) DefUse() DefUse {
+	du := make(DefUse, len(g.IDs))
+	for _, id := range g.IDs {
+		du[id] = g.Expr(id)
+	}
+	return du
+}
+
+// DefUse implements analyses.DefUse for the statements of a stringlang CFG, by label
+type DefUse map[int]ast.Expr
+
+func (du DefUse) Defs(label int) []string {
+	if assn, ok := du[label].(ast.Assn); ok {
+		return []string{string(assn.V)}
+	}
+	return []string{}
+}
+
+func (du DefUse) Uses(label int) []string {
+	return analyses.VarSet(ast.UsedVars([]ast.Expr{du[label]})).Sorted()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"github.com/skius/dataflowanalysis/analyses"
+	"github.com/skius/dataflowanalysis/examples/stringlang/cfgadapter"
+	"github.com/skius/stringlang"
+	"github.com/skius/stringlang/ast"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Usage: slice [label variable]
+// Slices program.stringlang by the value of variable at the node label, by default the last node and the first
+// variable it reads
+func main() {
+	f, err := ioutil.ReadFile("program.stringlang")
+	if err != nil {
+		panic(err)
+	}
+	expr, err := stringlang.Parse(f)
+	if err != nil {
+		panic(err)
+	}
+
+	graph := cfgadapter.New(expr.(ast.Program))
+	ids := graph.IDs
+	du := graph.DefUse()
+
+	fmt.Println("Program:")
+	for _, id := range ids {
+		fmt.Println(id, ": ", du[id].String())
+	}
+
+	criterion := analyses.Criterion{Label: ids[len(ids)-1]}
+	if uses := du.Uses(criterion.Label); len(uses) > 0 {
+		criterion.Var = uses[0]
+	}
+	if len(os.Args) == 3 {
+		label, err := strconv.Atoi(os.Args[1])
+		if err != nil {
+			panic(err)
+		}
+		criterion = analyses.Criterion{Label: label, Var: os.Args[2]}
+	}
+
+	pdg := analyses.BuildPDG([]int{graph.Entry}, ids, graph.Nodes, du)
+
+	fmt.Println()
+	fmt.Println("Control dependences:")
+	for _, id := range ids {
+		fmt.Println(id, ": ", pdg.Control[id])
+	}
+
+	backward := pdg.BackwardSlice(criterion)
+	fmt.Println()
+	fmt.Printf("Backward slice of %s at %d: %v\n", criterion.Var, criterion.Label, backward)
+	fmt.Println(sliceBlock(graph.Program.Code, backward, new(int)))
+
+	forward := pdg.ForwardSlice(criterion)
+	fmt.Println()
+	fmt.Printf("Forward slice of %s at %d: %v\n", criterion.Var, criterion.Label, forward)
+	fmt.Println(sliceBlock(graph.Program.Code, forward, new(int)))
+}
+
+// sliceBlock returns the statements of the block whose labels are in the slice, keeping the conditionals and loops
+// containing them. It numbers the statements the way cfg.New labels them, ctr is the last label used.
+func sliceBlock(block ast.Block, slice []int, ctr *int) ast.Block {
+	inSlice := func(label int) bool {
+		i := sort.SearchInts(slice, label)
+		return i < len(slice) && slice[i] == label
+	}
+
+	res := make(ast.Block, 0, len(block))
+	for _, expr := range block {
+		*ctr++
+		label := *ctr
+
+		switch e := expr.(type) {
+		case ast.IfElse:
+			then := sliceBlock(e.Then.(ast.Block), slice, ctr)
+			els := sliceBlock(e.Else.(ast.Block), slice, ctr)
+			if inSlice(label) || len(then) > 0 || len(els) > 0 {
+				res = append(res, ast.IfElse{Cond: e.Cond, Then: then, Else: els})
+			}
+		case ast.While:
+			body := sliceBlock(e.Body.(ast.Block), slice, ctr)
+			if inSlice(label) || len(body) > 0 {
+				res = append(res, ast.While{Cond: e.Cond, Body: body})
+			}
+		default:
+			if inSlice(label) {
+				res = append(res, expr)
+			}
+		}
+	}
+	return res
+}
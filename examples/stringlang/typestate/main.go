@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/examples/stringlang/cfgadapter"
+	"github.com/skius/stringlang"
+	"github.com/skius/stringlang/ast"
+	"io/ioutil"
+)
+
+// Checks that program.stringlang opens the file before writing or closing it, once by separating the typestates
+// only and once with path predicates, which rule out the paths on which the two tests of mode disagree
+func main() {
+	f, err := ioutil.ReadFile("program.stringlang")
+	if err != nil {
+		panic(err)
+	}
+	expr, err := stringlang.Parse(f)
+	if err != nil {
+		panic(err)
+	}
+
+	graph := cfgadapter.New(expr.(ast.Program))
+	entries := []int{graph.Entry}
+
+	fmt.Println("Program:")
+	for _, id := range graph.IDs {
+		fmt.Println(id, ": ", graph.Expr(id).String())
+	}
+
+	statesOnly, _, _ := dfa.ESP(fileProperty, dfa.PathConditions{}, dfa.Analysis{}).Run(entries, graph.IDs, graph.Nodes)
+	fmt.Println()
+	fmt.Println("Typestate errors without path predicates:")
+	printErrors(graph, typestateErrors(graph.IDs, graph.Nodes, statesOnly))
+
+	esp, _, _ := dfa.ESP(fileProperty, pathConditions(graph), dfa.Analysis{}).Run(entries, graph.IDs, graph.Nodes)
+	fmt.Println()
+	fmt.Println("Typestate errors with path predicates:")
+	printErrors(graph, typestateErrors(graph.IDs, graph.Nodes, esp))
+}
+
+func printErrors(graph *cfgadapter.Graph, errs []typestateError) {
+	for _, e := range errs {
+		fmt.Println(e.Label, ": ", graph.Expr(e.Label).String(), "while", e.State)
+		fmt.Println("  on paths where", e.Fact)
+	}
+}
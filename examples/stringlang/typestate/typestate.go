@@ -0,0 +1,89 @@
+package main
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/examples/stringlang/cfgadapter"
+	"github.com/skius/stringlang/ast"
+)
+
+// The typestates of the file the program opens, writes and closes
+const (
+	closed    = "closed"
+	open      = "open"
+	violation = "error"
+)
+
+// fileProperty is the typestate property of the file: it must be opened before it is written or closed, and not
+// opened twice
+var fileProperty = dfa.Property{
+	Initial: closed,
+	Step: func(state string, n dfa.Node) string {
+		call, ok := n.Get().(ast.Call)
+		if !ok || state == violation {
+			return state
+		}
+		switch string(call.Fn) {
+		case "open":
+			if state == closed {
+				return open
+			}
+			return violation
+		case "write":
+			if state == open {
+				return open
+			}
+			return violation
+		case "close":
+			if state == open {
+				return closed
+			}
+			return violation
+		}
+		return state
+	},
+}
+
+// pathConditions tracks the conditions of all branches, which stop holding when one of their variables is assigned
+func pathConditions(graph *cfgadapter.Graph) dfa.PathConditions {
+	condVars := make(map[string]map[string]struct{})
+	for _, id := range graph.IDs {
+		if len(graph.Nodes[id].SuccsTaken()) > 0 {
+			cond := graph.Expr(id)
+			condVars[cond.String()] = ast.UsedVars([]ast.Expr{cond})
+		}
+	}
+
+	return dfa.PathConditions{
+		Cond: func(n dfa.Node) (string, bool) {
+			return n.Get().(ast.Expr).String(), true
+		},
+		Kills: func(cond string, n dfa.Node) bool {
+			assn, ok := n.Get().(ast.Assn)
+			if !ok {
+				return false
+			}
+			_, reads := condVars[cond][string(assn.V)]
+			return reads
+		},
+	}
+}
+
+// A typestateError is a node at which the file may be used in the wrong state
+type typestateError struct {
+	Label int
+	State string
+	Fact  dfa.Fact // The ESPState of the traces reaching the node in State
+}
+
+// typestateErrors returns the nodes at which some state reaching them steps into the error state
+func typestateErrors(ids []int, idToNode map[int]dfa.Node, in map[int]dfa.Fact) []typestateError {
+	errs := make([]typestateError, 0)
+	for _, id := range ids {
+		for _, p := range in[id].(dfa.DisjunctiveFact).Partitions {
+			if p.Key != violation && fileProperty.Step(p.Key, idToNode[id]) == violation {
+				errs = append(errs, typestateError{Label: id, State: p.Key, Fact: p.Fact})
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,229 @@
+//go:build go1.18
+// +build go1.18
+
+package dataflowanalysis_test
+
+import (
+	"fmt"
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/randcfg"
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+// A bitset is a Fact of a gen/kill analysis over 64 abstract definitions
+type bitset uint64
+
+func (b bitset) Equals(f dfa.Fact) bool {
+	return b == f.(bitset)
+}
+
+func (b bitset) String() string {
+	return fmt.Sprintf("%#x", uint64(b))
+}
+
+func union(a, b dfa.Fact) dfa.Fact {
+	return a.(bitset) | b.(bitset)
+}
+
+// A genKill is a random monotone gen/kill analysis, with separate transfer functions for taken edges
+type genKill struct {
+	gen, kill           map[int]bitset
+	genTaken, killTaken map[int]bitset
+	entry               bitset
+}
+
+func newGenKill(seed int64, ids []int) *genKill {
+	r := rand.New(rand.NewSource(seed))
+	sparse := func() bitset {
+		// Few bits, so that facts grow over several iterations
+		return bitset(r.Uint64() & r.Uint64() & r.Uint64())
+	}
+	a := &genKill{
+		gen:       make(map[int]bitset, len(ids)),
+		kill:      make(map[int]bitset, len(ids)),
+		genTaken:  make(map[int]bitset, len(ids)),
+		killTaken: make(map[int]bitset, len(ids)),
+		entry:     sparse(),
+	}
+	for _, id := range ids {
+		a.gen[id], a.kill[id] = sparse(), sparse()
+		a.genTaken[id], a.killTaken[id] = sparse(), sparse()
+	}
+	return a
+}
+
+func (a *genKill) transfer(f bitset, label int) (notTaken, taken bitset) {
+	return f&^a.kill[label] | a.gen[label], f&^a.killTaken[label] | a.genTaken[label]
+}
+
+func (a *genKill) flow(f dfa.Fact, n dfa.Node) (dfa.Fact, dfa.Fact) {
+	notTaken, taken := a.transfer(f.(bitset), n.Label())
+	return notTaken, taken
+}
+
+func (a *genKill) flowPI(f dfa.Fact, n dfa.NodePI) dfa.Fact {
+	notTaken, _ := a.transfer(f.(bitset), n.Label())
+	return notTaken
+}
+
+// fuzzGraph generates the graph described by the fuzzer's inputs, shape packs the number of irreducible edges,
+// unreachable regions, entries and the branching factor into two bits each
+func fuzzGraph(seed int64, nodes uint8, shape uint8) *dfa.Graph {
+	return randcfg.Generate(seed, randcfg.Config{
+		Nodes:       1 + int(nodes%64),
+		Irreducible: int(shape & 3),
+		Unreachable: int(shape >> 2 & 3),
+		Entries:     1 + int(shape>>4&3),
+		Branching:   2 + int(shape>>6),
+	})
+}
+
+// referenceSolve naively solves an analysis by round-robin iteration over ids until nothing changes, taking the
+// predecessors of each node from the maps, so that it knows nothing about the solvers' wrappers
+func referenceSolve(
+	entryIds []int,
+	ids []int,
+	predsNotTaken, predsTaken map[int][]int,
+	transfer func(bitset, int) (bitset, bitset),
+	entry bitset,
+) (in, outNotTaken, outTaken map[int]bitset) {
+	in = make(map[int]bitset, len(ids))
+	outNotTaken = make(map[int]bitset, len(ids))
+	outTaken = make(map[int]bitset, len(ids))
+	isEntry := make(map[int]bool)
+	for _, id := range entryIds {
+		isEntry[id] = true
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, id := range ids {
+			var f bitset
+			for _, pred := range predsNotTaken[id] {
+				f |= outNotTaken[pred]
+			}
+			for _, pred := range predsTaken[id] {
+				f |= outTaken[pred]
+			}
+			if isEntry[id] {
+				f |= entry
+			}
+
+			notTaken, taken := transfer(f, id)
+			if f != in[id] || notTaken != outNotTaken[id] || taken != outTaken[id] {
+				in[id], outNotTaken[id], outTaken[id] = f, notTaken, taken
+				changed = true
+			}
+		}
+	}
+	return in, outNotTaken, outTaken
+}
+
+func compareFacts(t *testing.T, solver, name string, ids []int, got map[int]dfa.Fact, want map[int]bitset) {
+	t.Helper()
+	for _, id := range ids {
+		b, ok := got[id].(bitset)
+		if !ok {
+			t.Fatalf("%s: %s(%d) = %v, the reference computes %v", solver, name, id, got[id], want[id])
+		}
+		if b != want[id] {
+			t.Fatalf("%s: %s(%d) = %v, the reference computes %v (%d bits off)", solver, name, id, b, want[id],
+				bits.OnesCount64(uint64(b^want[id])))
+		}
+	}
+}
+
+func addFuzzSeeds(f *testing.F) {
+	f.Add(int64(0), uint8(10), uint8(0), int64(0))
+	f.Add(int64(1), uint8(40), uint8(0x15), int64(1))
+	f.Add(int64(2), uint8(63), uint8(0xff), int64(2))
+	f.Add(int64(3), uint8(1), uint8(0x30), int64(3))
+	f.Add(int64(4), uint8(25), uint8(0x42), int64(4))
+}
+
+func FuzzRunForward(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, seed int64, nodes uint8, shape uint8, analysis int64) {
+		g := fuzzGraph(seed, nodes, shape)
+		ids, idToNode := g.IDs(), g.Nodes()
+		a := newGenKill(analysis, ids)
+
+		predsNotTaken, predsTaken := make(map[int][]int), make(map[int][]int)
+		for _, id := range ids {
+			predsNotTaken[id], predsTaken[id] = idToNode[id].PredsNotTaken(), idToNode[id].PredsTaken()
+		}
+		in, outNotTaken, outTaken := referenceSolve(g.Entries, ids, predsNotTaken, predsTaken, a.transfer, a.entry)
+
+		type solution = func() (map[int]dfa.Fact, map[int]dfa.Fact, map[int]dfa.Fact)
+		solvers := []struct {
+			name string
+			run  solution
+		}{
+			{"RunForward", func() (map[int]dfa.Fact, map[int]dfa.Fact, map[int]dfa.Fact) {
+				return dfa.RunForward(g.Entries, ids, idToNode, union, a.flow, bitset(0), a.entry)
+			}},
+			{"RunForwardWTO", func() (map[int]dfa.Fact, map[int]dfa.Fact, map[int]dfa.Fact) {
+				return dfa.RunForwardWTO(g.Entries, ids, idToNode, union, a.flow, bitset(0), a.entry, nil)
+			}},
+			{"RunForwardElimination", func() (map[int]dfa.Fact, map[int]dfa.Fact, map[int]dfa.Fact) {
+				return dfa.RunForwardElimination(g.Entries, ids, idToNode, union, a.flow, bitset(0), a.entry)
+			}},
+		}
+		for _, s := range solvers {
+			gotIn, gotOutNotTaken, gotOutTaken := s.run()
+			compareFacts(t, s.name, "in", ids, gotIn, in)
+			compareFacts(t, s.name, "outNotTaken", ids, gotOutNotTaken, outNotTaken)
+			compareFacts(t, s.name, "outTaken", ids, gotOutTaken, outTaken)
+		}
+	})
+}
+
+func FuzzRunForwardPI(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, seed int64, nodes uint8, shape uint8, analysis int64) {
+		g := fuzzGraph(seed, nodes, shape)
+		ids, idToNode := g.IDs(), g.NodesPI()
+		a := newGenKill(analysis, ids)
+
+		preds := make(map[int][]int)
+		for _, id := range ids {
+			preds[id] = idToNode[id].Preds()
+		}
+		in, out, _ := referenceSolve(g.Entries, ids, preds, nil, a.transfer, a.entry)
+
+		gotIn, gotOut := dfa.RunForwardPI(g.Entries, ids, idToNode, union, a.flowPI, bitset(0), a.entry)
+		compareFacts(t, "RunForwardPI", "in", ids, gotIn, in)
+		compareFacts(t, "RunForwardPI", "out", ids, gotOut, out)
+	})
+}
+
+func FuzzRunBackwardPI(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, seed int64, nodes uint8, shape uint8, analysis int64) {
+		g := fuzzGraph(seed, nodes, shape)
+		ids, idToNode := g.IDs(), g.NodesPI()
+		a := newGenKill(analysis, ids)
+
+		// Backward, the facts flow out of the successors and the exits are the boundary
+		succs := make(map[int][]int)
+		exits := make([]int, 0)
+		for _, id := range ids {
+			succs[id] = idToNode[id].Succs()
+			if len(succs[id]) == 0 {
+				exits = append(exits, id)
+			}
+		}
+
+		out, in, _ := referenceSolve(nil, ids, succs, nil, a.transfer, 0)
+		gotIn, gotOut := dfa.RunBackwardPI(ids, idToNode, union, a.flowPI, bitset(0))
+		compareFacts(t, "RunBackwardPI", "in", ids, gotIn, in)
+		compareFacts(t, "RunBackwardPI", "out", ids, gotOut, out)
+
+		out, in, _ = referenceSolve(exits, ids, succs, nil, a.transfer, a.entry)
+		gotIn, gotOut = dfa.RunBackwardPIFrom(exits, ids, idToNode, union, a.flowPI, bitset(0), a.entry)
+		compareFacts(t, "RunBackwardPIFrom", "in", ids, gotIn, in)
+		compareFacts(t, "RunBackwardPIFrom", "out", ids, gotOut, out)
+	})
+}
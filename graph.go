@@ -0,0 +1,118 @@
+package dataflowanalysis
+
+import "sort"
+
+// Exits returns the labels of the nodes without successors, sorted
+func Exits(ids []int, idToNode map[int]Node) []int {
+	exits := make([]int, 0)
+	for _, id := range ids {
+		n := idToNode[id]
+		if len(n.SuccsNotTaken())+len(n.SuccsTaken()) == 0 {
+			exits = append(exits, id)
+		}
+	}
+	sort.Ints(exits)
+	return exits
+}
+
+// ExitsPI returns the labels of the path-insensitive nodes without successors, sorted
+func ExitsPI(ids []int, idToNode map[int]NodePI) []int {
+	return Exits(ids, wrapPI(idToNode))
+}
+
+// Unreachable returns the labels of the nodes that cannot be reached from the entries, sorted
+func Unreachable(entryIds []int, ids []int, idToNode map[int]Node) []int {
+	v := newView(ids, idToNode)
+	reached := make([]bool, len(ids))
+	for _, i := range v.indicesOf(entryIds) {
+		if reached[i] {
+			continue
+		}
+		for _, j := range dfsPostorder(i, func(k int) []int { return v.succs[k] }) {
+			reached[j] = true
+		}
+	}
+
+	unreachable := make([]int, 0)
+	for i, ok := range reached {
+		if !ok {
+			unreachable = append(unreachable, i)
+		}
+	}
+	return v.labelsOf(unreachable)
+}
+
+// UnreachablePI returns the labels of the path-insensitive nodes that cannot be reached from the entries, sorted
+func UnreachablePI(entryIds []int, ids []int, idToNode map[int]NodePI) []int {
+	return Unreachable(entryIds, ids, wrapPI(idToNode))
+}
+
+// A view is the index-based adjacency lists of a graph, ignoring the kind of the edges.
+// The graph algorithms work on views so they can add virtual nodes and reverse edges freely.
+type view struct {
+	labels []int       // The label of each index
+	index  map[int]int // The index of each label
+	succs  [][]int     // Successor indices, without duplicates
+	preds  [][]int     // Predecessor indices, without duplicates
+}
+
+func newView(ids []int, idToNode map[int]Node) *view {
+	v := new(view)
+	v.labels = make([]int, len(ids))
+	v.index = make(map[int]int, len(ids))
+	for i, id := range ids {
+		v.labels[i] = id
+		v.index[id] = i
+	}
+
+	v.succs = make([][]int, len(ids))
+	v.preds = make([][]int, len(ids))
+	for i, id := range ids {
+		n := idToNode[id]
+		seen := make(map[int]bool)
+		for _, succs := range [][]int{n.SuccsNotTaken(), n.SuccsTaken()} {
+			for _, succ := range succs {
+				j, ok := v.index[succ]
+				if !ok || seen[j] {
+					continue
+				}
+				seen[j] = true
+				v.succs[i] = append(v.succs[i], j)
+				v.preds[j] = append(v.preds[j], i)
+			}
+		}
+	}
+
+	return v
+}
+
+// reversed returns the view with every edge flipped
+func (v *view) reversed() *view {
+	rev := new(view)
+	rev.labels = v.labels
+	rev.index = v.index
+	rev.succs = v.preds
+	rev.preds = v.succs
+	return rev
+}
+
+// indicesOf returns the indices of the given labels, skipping unknown ones
+func (v *view) indicesOf(ids []int) []int {
+	indices := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if i, ok := v.index[id]; ok {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// labelsOf returns the sorted labels of the given indices
+func (v *view) labelsOf(indices []int) []int {
+	labels := make([]int, len(indices))
+	for i, idx := range indices {
+		labels[i] = v.labels[idx]
+	}
+	sort.Ints(labels)
+	return labels
+}
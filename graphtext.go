@@ -0,0 +1,265 @@
+package dataflowanalysis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+	The textual graph format, statements separated by semicolons or newlines:
+		entry 1              1 is an entry
+		1 -> 2 -> 3          Not-taken (fall-through) edges, may be chained
+		2 -T-> 4             A taken edge
+		2 -F-> 3             A not-taken edge, the same as ->
+		5                    A node without edges
+		3 "x = a + b"        A node with a statement, a Go string literal
+	# starts a comment that runs to the end of the line.
+*/
+
+// ParseGraph parses the textual description of a graph, e.g. "1 -> 2; 2 -T-> 4; 2 -F-> 3; entry 1".
+// Statements given as string literals are attached as strings.
+func ParseGraph(text string) (*Graph, error) {
+	tokens, err := tokenizeGraph(text)
+	if err != nil {
+		return nil, err
+	}
+
+	g := NewGraph()
+	for i := 0; i < len(tokens); {
+		stmt := make([]graphToken, 0)
+		for ; i < len(tokens) && tokens[i].kind != tokSep; i++ {
+			stmt = append(stmt, tokens[i])
+		}
+		i++
+		if len(stmt) > 0 {
+			if err := g.parseStatement(stmt); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return g, nil
+}
+
+// MustParseGraph is like ParseGraph but panics on errors, for graphs written inline in tests
+func MustParseGraph(text string) *Graph {
+	g, err := ParseGraph(text)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+func (g *Graph) parseStatement(stmt []graphToken) error {
+	first := stmt[0]
+	switch {
+	case first.kind == tokEntry:
+		if len(stmt) != 2 || stmt[1].kind != tokLabel {
+			return first.errorf("expected a label after entry")
+		}
+		g.SetEntry(stmt[1].label)
+		return nil
+
+	case first.kind != tokLabel:
+		return first.errorf("expected a label or entry, found %s", first.text)
+
+	case len(stmt) == 1:
+		g.node(first.label)
+		return nil
+
+	case stmt[1].kind == tokString:
+		if len(stmt) != 2 {
+			return stmt[2].errorf("unexpected %s after statement", stmt[2].text)
+		}
+		g.AddNode(first.label, stmt[1].str)
+		return nil
+	}
+
+	// A chain of edges, label (arrow label)+
+	from := first.label
+	for j := 1; j < len(stmt); j += 2 {
+		arrow := stmt[j]
+		if arrow.kind != tokArrow && arrow.kind != tokTaken && arrow.kind != tokNotTaken {
+			return arrow.errorf("expected an arrow, found %s", arrow.text)
+		}
+		if j+1 >= len(stmt) || stmt[j+1].kind != tokLabel {
+			return arrow.errorf("expected a label after %s", arrow.text)
+		}
+		to := stmt[j+1].label
+		g.AddEdge(from, to, arrow.kind == tokTaken)
+		from = to
+	}
+	return nil
+}
+
+// String prints the graph in the textual format ParseGraph reads: the entries, then every node in label order with
+// its statement if it is a string and its outgoing edges
+func (g *Graph) String() string {
+	stmts := make([]string, 0, len(g.nodes)+len(g.Entries))
+	for _, e := range g.Entries {
+		stmts = append(stmts, "entry "+strconv.Itoa(e))
+	}
+
+	hasEdges := make(map[int]bool)
+	for _, n := range g.nodes {
+		for _, succ := range n.Succs() {
+			hasEdges[n.label] = true
+			hasEdges[succ] = true
+		}
+	}
+
+	for _, id := range g.IDs() {
+		n := g.nodes[id]
+		if s, ok := n.stmt.(string); ok {
+			stmts = append(stmts, strconv.Itoa(id)+" "+strconv.Quote(s))
+		} else if !hasEdges[id] && !containsInt(g.Entries, id) {
+			stmts = append(stmts, strconv.Itoa(id))
+		}
+
+		// Branches spell out their not-taken edges
+		arrow := " -> "
+		if len(n.succsTaken) > 0 {
+			arrow = " -F-> "
+		}
+		for _, succ := range n.succsNotTaken {
+			stmts = append(stmts, strconv.Itoa(id)+arrow+strconv.Itoa(succ))
+		}
+		for _, succ := range n.succsTaken {
+			stmts = append(stmts, strconv.Itoa(id)+" -T-> "+strconv.Itoa(succ))
+		}
+	}
+	return strings.Join(stmts, "; ")
+}
+
+type graphTokenKind int
+
+const (
+	tokLabel graphTokenKind = iota
+	tokEntry
+	tokArrow
+	tokTaken
+	tokNotTaken
+	tokString
+	tokSep
+)
+
+type graphToken struct {
+	kind      graphTokenKind
+	text      string
+	label     int
+	str       string
+	line, col int
+}
+
+func (t graphToken) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%d:%d: %s", t.line, t.col, fmt.Sprintf(format, args...))
+}
+
+func tokenizeGraph(text string) ([]graphToken, error) {
+	tokens := make([]graphToken, 0)
+	runes := []rune(text)
+	line, col := 1, 1
+	advance := func(n int) {
+		for k := 0; k < n; k++ {
+			if runes[k] == '\n' {
+				line, col = line+1, 1
+			} else {
+				col++
+			}
+		}
+		runes = runes[n:]
+	}
+
+	for len(runes) > 0 {
+		r := runes[0]
+		tok := graphToken{line: line, col: col}
+		switch {
+		case r == '\n' || r == ';':
+			tok.kind, tok.text = tokSep, string(r)
+			tokens = append(tokens, tok)
+			advance(1)
+
+		case unicode.IsSpace(r):
+			advance(1)
+
+		case r == '#':
+			n := 0
+			for n < len(runes) && runes[n] != '\n' {
+				n++
+			}
+			advance(n)
+
+		case unicode.IsDigit(r) || (r == '-' && len(runes) > 1 && unicode.IsDigit(runes[1])):
+			n := 1
+			for n < len(runes) && unicode.IsDigit(runes[n]) {
+				n++
+			}
+			label, err := strconv.Atoi(string(runes[:n]))
+			if err != nil {
+				return nil, tok.errorf("invalid label %s", string(runes[:n]))
+			}
+			tok.kind, tok.text, tok.label = tokLabel, string(runes[:n]), label
+			tokens = append(tokens, tok)
+			advance(n)
+
+		case hasPrefix(runes, "->"):
+			tok.kind, tok.text = tokArrow, "->"
+			tokens = append(tokens, tok)
+			advance(2)
+
+		case hasPrefix(runes, "-T->"):
+			tok.kind, tok.text = tokTaken, "-T->"
+			tokens = append(tokens, tok)
+			advance(4)
+
+		case hasPrefix(runes, "-F->"):
+			tok.kind, tok.text = tokNotTaken, "-F->"
+			tokens = append(tokens, tok)
+			advance(4)
+
+		case hasPrefix(runes, "entry") &&
+			(len(runes) == 5 || !unicode.IsLetter(runes[5]) && !unicode.IsDigit(runes[5])):
+			tok.kind, tok.text = tokEntry, "entry"
+			tokens = append(tokens, tok)
+			advance(5)
+
+		case r == '"':
+			n := 1
+			for n < len(runes) && runes[n] != '"' && runes[n] != '\n' {
+				if runes[n] == '\\' {
+					n++
+				}
+				n++
+			}
+			if n >= len(runes) || runes[n] != '"' {
+				return nil, tok.errorf("unterminated string")
+			}
+			n++
+			str, err := strconv.Unquote(string(runes[:n]))
+			if err != nil {
+				return nil, tok.errorf("invalid string %s", string(runes[:n]))
+			}
+			tok.kind, tok.text, tok.str = tokString, string(runes[:n]), str
+			tokens = append(tokens, tok)
+			advance(n)
+
+		default:
+			return nil, tok.errorf("unexpected %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+// hasPrefix returns whether runes starts with the ASCII prefix, without converting the rest of the input
+func hasPrefix(runes []rune, prefix string) bool {
+	if len(runes) < len(prefix) {
+		return false
+	}
+	for i := 0; i < len(prefix); i++ {
+		if runes[i] != rune(prefix[i]) {
+			return false
+		}
+	}
+	return true
+}
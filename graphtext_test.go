@@ -0,0 +1,94 @@
+package dataflowanalysis_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"testing"
+)
+
+func TestParseGraphErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		err  string
+	}{
+		{name: "unterminated string", text: `1 "x = 1`, err: "1:3: unterminated string"},
+		{name: "string ending in an escaped quote", text: `1 "x = \"1\"`, err: "1:3: unterminated string"},
+		{name: "string ending at the line", text: "1 \"x\n\"", err: "1:3: unterminated string"},
+		{name: "invalid escape", text: `1 "\q"`, err: `1:3: invalid string "\q"`},
+		{name: "missing label after ->", text: "1 -> 2 ->", err: "1:8: expected a label after ->"},
+		{name: "missing label after -T->", text: "1 -T->; 2", err: "1:3: expected a label after -T->"},
+		{name: "missing label after -F->", text: "1 -F-> entry", err: "1:3: expected a label after -F->"},
+		{name: "missing label after entry", text: "1 -> 2\nentry", err: "2:1: expected a label after entry"},
+		{name: "two labels after entry", text: "entry 1 2", err: "1:1: expected a label after entry"},
+		{name: "overflowing label", text: "1 -> 99999999999999999999", err: "1:6: invalid label 99999999999999999999"},
+		{name: "stray character", text: "entry 1\n1 -> 2 $", err: "2:8: unexpected '$'"},
+		{name: "stray letter", text: "entryway 1", err: "1:1: unexpected 'e'"},
+		{name: "missing arrow", text: "1 2", err: "1:3: expected an arrow, found 2"},
+		{name: "statement in a chain", text: `1 "x" -> 2`, err: "1:7: unexpected -> after statement"},
+		{name: "arrow first", text: "-> 1", err: "1:1: expected a label or entry, found ->"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := dfa.ParseGraph(tt.text)
+			if err == nil {
+				t.Fatalf("ParseGraph(%q) = %v, want error %q", tt.text, g, tt.err)
+			}
+			if err.Error() != tt.err {
+				t.Errorf("ParseGraph(%q) error = %q, want %q", tt.text, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestGraphString(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string // The String of the parsed graph
+	}{
+		{
+			name: "loop",
+			text: "entry 1; 1 -> 2 -> 3 -> 2; 2 -T-> 4",
+			want: "entry 1; 1 -> 2; 2 -F-> 3; 2 -T-> 4; 3 -> 2",
+		},
+		{
+			name: "escaped quotes",
+			text: `entry 1; 1 -> 2; 1 "print \"a\\b\""; 2 "x = \"\""`,
+			want: `entry 1; 1 "print \"a\\b\""; 1 -> 2; 2 "x = \"\""`,
+		},
+		{
+			name: "negative labels",
+			text: "entry -1; -1 -T-> -2; -1 -F-> 0; -3 # a node without edges",
+			want: "entry -1; -3; -1 -F-> 0; -1 -T-> -2",
+		},
+		{
+			// Nodes without edges are printed alone unless they are entries
+			name: "single nodes",
+			text: "entry 1\n2\n3 \"skip\"",
+			want: `entry 1; 2; 3 "skip"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := dfa.MustParseGraph(tt.text)
+			if got := g.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+
+			again, err := dfa.ParseGraph(g.String())
+			if err != nil {
+				t.Fatalf("ParseGraph(String()): %v", err)
+			}
+			if got := again.String(); got != tt.want {
+				t.Errorf("String() after the round-trip = %s, want %s", got, tt.want)
+			}
+			for _, id := range g.IDs() {
+				if got, want := again.Node(id).Get(), g.Node(id).Get(); got != want {
+					t.Errorf("statement of %d = %v after the round-trip, want %v", id, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package dataflowanalysis
+
+import "sort"
+
+// A Graph is a concrete, mutable graph whose nodes implement both Node and NodePI, so that users of the solvers only
+// need to implement their statement type. It is built with AddNode, AddEdge and SetEntry, the predecessors of each node
+// are maintained from the edges.
+type Graph struct {
+	Entries []int // The labels of the entry nodes
+
+	nodes map[int]*GraphNode
+}
+
+// A GraphNode is a node of a Graph. Its accessors return copies, so the edges only change through the Graph.
+type GraphNode struct {
+	label         int
+	stmt          Stmt
+	predsNotTaken []int
+	predsTaken    []int
+	succsNotTaken []int
+	succsTaken    []int
+}
+
+// NewGraph returns an empty graph
+func NewGraph() *Graph {
+	return &Graph{Entries: []int{}, nodes: make(map[int]*GraphNode)}
+}
+
+// IDs returns the labels of all nodes, sorted
+func (g *Graph) IDs() []int {
+	ids := make([]int, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Nodes returns the nodes by label, ready for the path-sensitive solvers
+func (g *Graph) Nodes() map[int]Node {
+	idToNode := make(map[int]Node, len(g.nodes))
+	for id, n := range g.nodes {
+		idToNode[id] = n
+	}
+	return idToNode
+}
+
+// NodesPI returns the nodes by label, ready for the path-insensitive solvers
+func (g *Graph) NodesPI() map[int]NodePI {
+	idToNode := make(map[int]NodePI, len(g.nodes))
+	for id, n := range g.nodes {
+		idToNode[id] = n
+	}
+	return idToNode
+}
+
+// Node returns the node with the label, nil if there is none
+func (g *Graph) Node(label int) *GraphNode {
+	return g.nodes[label]
+}
+
+// AddNode adds a node with the statement, which Get returns. If the node exists, its statement is replaced.
+func (g *Graph) AddNode(label int, stmt Stmt) *GraphNode {
+	n := g.node(label)
+	n.stmt = stmt
+	return n
+}
+
+// AddEdge adds an edge from the node from to the node to, which is taken if the branch at from is taken, and
+// not-taken (fall-through) otherwise. Missing nodes are added without statements, and an edge that already exists
+// is not added again.
+func (g *Graph) AddEdge(from, to int, taken bool) {
+	f, t := g.node(from), g.node(to)
+	switch {
+	case taken && containsInt(f.succsTaken, to), !taken && containsInt(f.succsNotTaken, to):
+		return
+	case taken:
+		f.succsTaken = append(f.succsTaken, to)
+		t.predsTaken = append(t.predsTaken, from)
+	default:
+		f.succsNotTaken = append(f.succsNotTaken, to)
+		t.predsNotTaken = append(t.predsNotTaken, from)
+	}
+}
+
+// SetEntry marks the node as an entry, adding it if it is missing
+func (g *Graph) SetEntry(label int) {
+	g.node(label)
+	if !containsInt(g.Entries, label) {
+		g.Entries = append(g.Entries, label)
+	}
+}
+
+// SetStmt attaches the statement to the node, which Get returns. It panics if there is no such node.
+func (g *Graph) SetStmt(label int, stmt Stmt) {
+	n, ok := g.nodes[label]
+	if !ok {
+		panic("dataflowanalysis: SetStmt on unknown node")
+	}
+	n.stmt = stmt
+}
+
+// node returns the node with the label, adding it if there is none
+func (g *Graph) node(label int) *GraphNode {
+	n, ok := g.nodes[label]
+	if !ok {
+		n = &GraphNode{label: label}
+		g.nodes[label] = n
+	}
+	return n
+}
+
+func (n *GraphNode) Label() int {
+	return n.label
+}
+
+func (n *GraphNode) PredsNotTaken() []int {
+	return append([]int{}, n.predsNotTaken...)
+}
+
+func (n *GraphNode) PredsTaken() []int {
+	return append([]int{}, n.predsTaken...)
+}
+
+func (n *GraphNode) SuccsNotTaken() []int {
+	return append([]int{}, n.succsNotTaken...)
+}
+
+func (n *GraphNode) SuccsTaken() []int {
+	return append([]int{}, n.succsTaken...)
+}
+
+func (n *GraphNode) Preds() []int {
+	return append(append([]int{}, n.predsNotTaken...), n.predsTaken...)
+}
+
+func (n *GraphNode) Succs() []int {
+	return append(append([]int{}, n.succsNotTaken...), n.succsTaken...)
+}
+
+func (n *GraphNode) Get() Stmt {
+	return n.stmt
+}
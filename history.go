@@ -0,0 +1,157 @@
+package dataflowanalysis
+
+import (
+	"fmt"
+	"sort"
+)
+
+// History records the facts of every node after every step of a solver run, a step being one evaluation of a flow
+// function. Pass an empty History to a solver using WithHistory, then inspect the run with StateAt, Diff and Bisect.
+//
+// For backward analyses the facts are recorded as in and out of the original graph, the out facts as OutNotTaken.
+type History struct {
+	Records []Record // The records of all steps, in order
+
+	initial  *State
+	backward bool
+	byLabel  map[int][]int // The indices into Records of each node's steps
+}
+
+// A Record holds the facts of a node right after it was evaluated in step Step, counting from 1
+type Record struct {
+	Step        int
+	Label       int
+	In          Fact
+	OutNotTaken Fact
+	OutTaken    Fact
+}
+
+// A State is the solver state between two steps
+type State struct {
+	Step        int // The number of steps taken so far
+	In          map[int]Fact
+	OutNotTaken map[int]Fact
+	OutTaken    map[int]Fact
+}
+
+// A Change is a fact that differs between two States
+type Change struct {
+	Label    int
+	Equation Equation // The equation defining the fact, i.e. which fact of the node changed
+	Before   Fact
+	After    Fact
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("node %d: %v %v -> %v", c.Label, c.Equation, c.Before, c.After)
+}
+
+// Steps returns the number of steps of the run
+func (h *History) Steps() int {
+	return len(h.Records)
+}
+
+// Node returns the records of the node, in order
+func (h *History) Node(label int) []Record {
+	res := make([]Record, len(h.byLabel[label]))
+	for i, idx := range h.byLabel[label] {
+		res[i] = h.Records[idx]
+	}
+	return res
+}
+
+// StateAt reconstructs the facts of all nodes after the given number of steps, StateAt(0) being the initial state
+// and StateAt(h.Steps()) the result of the run
+func (h *History) StateAt(step int) *State {
+	st := &State{
+		Step:        step,
+		In:          copyFacts(h.initial.In),
+		OutNotTaken: copyFacts(h.initial.OutNotTaken),
+		OutTaken:    copyFacts(h.initial.OutTaken),
+	}
+	for _, r := range h.Records {
+		if r.Step > step {
+			break
+		}
+		st.In[r.Label] = r.In
+		st.OutNotTaken[r.Label] = r.OutNotTaken
+		st.OutTaken[r.Label] = r.OutTaken
+	}
+	return st
+}
+
+// Diff returns the facts that differ between the states after steps from and to, sorted by label
+func (h *History) Diff(from, to int) []Change {
+	a, b := h.StateAt(from), h.StateAt(to)
+	labels := make([]int, 0, len(a.In))
+	for label := range a.In {
+		labels = append(labels, label)
+	}
+	sort.Ints(labels)
+
+	changes := make([]Change, 0)
+	diff := func(label int, eq Equation, before, after map[int]Fact) {
+		if !equalFacts(before[label], after[label]) {
+			changes = append(changes, Change{Label: label, Equation: eq, Before: before[label], After: after[label]})
+		}
+	}
+	for _, label := range labels {
+		if h.backward {
+			diff(label, InEquation, a.In, b.In)
+			diff(label, OutEquation, a.OutNotTaken, b.OutNotTaken)
+			continue
+		}
+		diff(label, InEquation, a.In, b.In)
+		diff(label, OutNotTakenEquation, a.OutNotTaken, b.OutNotTaken)
+		diff(label, OutTakenEquation, a.OutTaken, b.OutTaken)
+	}
+	return changes
+}
+
+// Bisect returns the first step after which bad holds, or -1 if it does not hold at the end of the run.
+// bad must keep holding once it holds, e.g. "node 4 has a fact it should never have".
+func (h *History) Bisect(bad func(*State) bool) int {
+	if !bad(h.StateAt(h.Steps())) {
+		return -1
+	}
+	return sort.Search(h.Steps()+1, func(step int) bool {
+		return bad(h.StateAt(step))
+	})
+}
+
+// start prepares the History for a run with the given initial facts
+func (h *History) start(in, outNotTaken, outTaken map[int]Fact, backward bool) {
+	h.Records = make([]Record, 0)
+	h.backward = backward
+	h.byLabel = make(map[int][]int, len(in))
+	if backward {
+		h.initial = &State{In: copyFacts(outNotTaken), OutNotTaken: copyFacts(in), OutTaken: make(map[int]Fact)}
+	} else {
+		h.initial = &State{In: copyFacts(in), OutNotTaken: copyFacts(outNotTaken), OutTaken: copyFacts(outTaken)}
+	}
+}
+
+// record adds the facts of the node after a step
+func (h *History) record(step, label int, in, outNotTaken, outTaken Fact) {
+	r := Record{Step: step, Label: label, In: in, OutNotTaken: outNotTaken, OutTaken: outTaken}
+	if h.backward {
+		r = Record{Step: step, Label: label, In: outNotTaken, OutNotTaken: in}
+	}
+	h.byLabel[label] = append(h.byLabel[label], len(h.Records))
+	h.Records = append(h.Records, r)
+}
+
+func copyFacts(facts map[int]Fact) map[int]Fact {
+	res := make(map[int]Fact, len(facts))
+	for k, v := range facts {
+		res[k] = v
+	}
+	return res
+}
+
+func equalFacts(a, b Fact) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equals(b)
+}
@@ -0,0 +1,91 @@
+package dataflowanalysis_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"reflect"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	g := dfa.MustParseGraph(loopGraph)
+	var h dfa.History
+	in, out := dfa.RunForwardWTOPI(g.Entries, g.IDs(), g.NodesPI(), counterMax, counterFlow, counter(0), counter(0),
+		counterWiden, dfa.WithHistory(&h))
+
+	// The WTO evaluates 1, then the loop twice before widening makes it stable, then 4
+	labels := make([]int, h.Steps())
+	for i, r := range h.Records {
+		labels[i] = r.Label
+	}
+	if want := []int{1, 2, 3, 2, 3, 4}; !reflect.DeepEqual(labels, want) {
+		t.Fatalf("steps evaluate %v, want %v", labels, want)
+	}
+	if got := h.Node(2); len(got) != 2 || got[0].Step != 2 || got[1].Step != 4 || got[1].In != counterTop {
+		t.Errorf("Node(2) = %+v", got)
+	}
+
+	initial := h.StateAt(0)
+	for _, id := range g.IDs() {
+		if initial.In[id] != counter(0) || initial.OutNotTaken[id] != counter(0) {
+			t.Errorf("StateAt(0) has in(%d) = %v and out(%d) = %v", id, initial.In[id], id, initial.OutNotTaken[id])
+		}
+	}
+	if st := h.StateAt(3); st.In[2] != counter(1) || st.OutNotTaken[3] != counter(3) || st.In[4] != counter(0) {
+		t.Errorf("StateAt(3) = %+v", st)
+	}
+	if final := h.StateAt(h.Steps()); !reflect.DeepEqual(final.In, in) || !reflect.DeepEqual(final.OutNotTaken, out) {
+		t.Errorf("StateAt(%d) = %+v, want the result %v, %v", h.Steps(), final, in, out)
+	}
+
+	// Widening at step 4 changes the head, the rest of the loop follows in step 5
+	wantDiff := []dfa.Change{
+		{Label: 2, Equation: dfa.InEquation, Before: counter(1), After: counterTop},
+		{Label: 2, Equation: dfa.OutNotTakenEquation, Before: counter(2), After: counterTop},
+		{Label: 3, Equation: dfa.InEquation, Before: counter(2), After: counterTop},
+		{Label: 3, Equation: dfa.OutNotTakenEquation, Before: counter(3), After: counterTop},
+	}
+	if got := h.Diff(3, 5); !reflect.DeepEqual(got, wantDiff) {
+		t.Errorf("Diff(3, 5) = %v, want %v", got, wantDiff)
+	}
+	if got := h.Diff(5, 5); len(got) != 0 {
+		t.Errorf("Diff(5, 5) = %v, want no changes", got)
+	}
+
+	tests := []struct {
+		name string
+		bad  func(*dfa.State) bool
+		want int
+	}{
+		{name: "head widened", bad: func(st *dfa.State) bool { return st.In[2] == counterTop }, want: 4},
+		{name: "exit reached", bad: func(st *dfa.State) bool { return st.In[4] != counter(0) }, want: 6},
+		{name: "entry changed", bad: func(st *dfa.State) bool { return st.In[1] != counter(0) }, want: -1},
+		{name: "always", bad: func(st *dfa.State) bool { return true }, want: 0},
+	}
+	for _, tt := range tests {
+		if got := h.Bisect(tt.bad); got != tt.want {
+			t.Errorf("Bisect(%s) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHistoryBackward(t *testing.T) {
+	// Backward histories record the facts of the original graph, the in facts merged from the successors' in facts
+	g := dfa.MustParseGraph("entry 1; 1 -> 2 -> 3")
+	var h dfa.History
+	in, out := dfa.RunBackwardPI(g.IDs(), g.NodesPI(), maskUnion, visitedPI, mask(0), dfa.WithHistory(&h))
+
+	final := h.StateAt(h.Steps())
+	if !reflect.DeepEqual(final.In, in) || !reflect.DeepEqual(final.OutNotTaken, out) {
+		t.Errorf("StateAt(%d) = %+v, want the result %v, %v", h.Steps(), final, in, out)
+	}
+	for _, c := range h.Diff(0, h.Steps()) {
+		if c.Equation != dfa.InEquation && c.Equation != dfa.OutEquation {
+			t.Errorf("Diff has the change %v of a path-sensitive equation", c)
+		}
+	}
+	// The in fact of 1 is complete once 1 is evaluated after 2, whichever order the worklist takes
+	step := h.Bisect(func(st *dfa.State) bool { return st.In[1] == maskOf(1, 2, 3) })
+	if step <= 0 || h.Records[step-1].Label != 1 {
+		t.Errorf("in(1) is complete after step %d, which does not evaluate 1", step)
+	}
+}
@@ -0,0 +1,320 @@
+package dataflowanalysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// A FactCodec converts the facts of a domain to and from JSON
+type FactCodec interface {
+	Encode(f Fact) (json.RawMessage, error)
+	Decode(data json.RawMessage) (Fact, error)
+}
+
+var (
+	codecsMu     sync.RWMutex
+	codecsByName = make(map[string]FactCodec)
+	namesByType  = make(map[reflect.Type]string)
+)
+
+// RegisterFactCodec makes facts of the same type as sample round-trip through JSON using codec, under the given name.
+// Facts of unregistered types are encoded by their String and decode to a TextFact.
+func RegisterFactCodec(name string, sample Fact, codec FactCodec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if _, ok := codecsByName[name]; ok {
+		panic("dataflowanalysis: fact codec registered twice: " + name)
+	}
+	codecsByName[name] = codec
+	namesByType[reflect.TypeOf(sample)] = name
+}
+
+// RegisterFact registers facts of the same type as sample under the given name, encoding them with encoding/json.
+// The type must round-trip through json.Marshal and json.Unmarshal.
+func RegisterFact(name string, sample Fact) {
+	RegisterFactCodec(name, sample, jsonCodec{reflect.TypeOf(sample)})
+}
+
+// A jsonCodec encodes facts with encoding/json
+type jsonCodec struct {
+	t reflect.Type
+}
+
+func (c jsonCodec) Encode(f Fact) (json.RawMessage, error) {
+	return json.Marshal(f)
+}
+
+func (c jsonCodec) Decode(data json.RawMessage) (Fact, error) {
+	if c.t.Kind() == reflect.Ptr {
+		v := reflect.New(c.t.Elem())
+		if err := json.Unmarshal(data, v.Interface()); err != nil {
+			return nil, err
+		}
+		return v.Interface().(Fact), nil
+	}
+	v := reflect.New(c.t)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface().(Fact), nil
+}
+
+// A TextFact is a fact decoded from JSON without a registered codec, it only knows its String
+type TextFact string
+
+func (t TextFact) Equals(other Fact) bool {
+	o, ok := other.(TextFact)
+	return ok && o == t
+}
+
+func (t TextFact) String() string {
+	return string(t)
+}
+
+// encodedFact is the JSON form of a Fact
+type encodedFact struct {
+	Type  string          `json:"type,omitempty"`  // The name of the codec, empty if unregistered
+	Value json.RawMessage `json:"value,omitempty"` // The encoded fact, if the codec is registered
+	Text  string          `json:"text"`            // The String of the fact
+}
+
+// MarshalFact encodes the fact, using its registered codec if any
+func MarshalFact(f Fact) ([]byte, error) {
+	e, err := encodeFact(f)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(e)
+}
+
+// UnmarshalFact decodes a fact encoded by MarshalFact
+func UnmarshalFact(data []byte) (Fact, error) {
+	var e encodedFact
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return decodeFact(&e)
+}
+
+func encodeFact(f Fact) (*encodedFact, error) {
+	if f == nil {
+		return nil, nil
+	}
+	e := &encodedFact{Text: f.String()}
+
+	codecsMu.RLock()
+	name, ok := namesByType[reflect.TypeOf(f)]
+	codec := codecsByName[name]
+	codecsMu.RUnlock()
+	if !ok {
+		return e, nil
+	}
+
+	value, err := codec.Encode(f)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s fact: %w", name, err)
+	}
+	e.Type, e.Value = name, value
+	return e, nil
+}
+
+func decodeFact(e *encodedFact) (Fact, error) {
+	if e == nil {
+		return nil, nil
+	}
+	if e.Type == "" {
+		return TextFact(e.Text), nil
+	}
+
+	codecsMu.RLock()
+	codec, ok := codecsByName[e.Type]
+	codecsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no fact codec registered for %q", e.Type)
+	}
+	f, err := codec.Decode(e.Value)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s fact: %w", e.Type, err)
+	}
+	return f, nil
+}
+
+// A Result bundles the outcome of a solver run for persisting it as JSON
+type Result struct {
+	In          map[int]Fact
+	OutNotTaken map[int]Fact
+	OutTaken    map[int]Fact // Nil for path-insensitive analyses
+	Unreachable []int        // The labels of the nodes unreachable from the entries, sorted
+	Stats       *Stats       // Nil if not counted
+}
+
+// NewResult bundles the outcome of RunForward, or of the other solvers with a nil outTaken, computing the
+// unreachable nodes from the graph
+func NewResult(entryIds []int, ids []int, idToNode map[int]Node, in, outNotTaken, outTaken map[int]Fact, stats *Stats) *Result {
+	return &Result{
+		In:          in,
+		OutNotTaken: outNotTaken,
+		OutTaken:    outTaken,
+		Unreachable: Unreachable(entryIds, ids, idToNode),
+		Stats:       stats,
+	}
+}
+
+type resultNode struct {
+	Label       int          `json:"label"`
+	In          *encodedFact `json:"in,omitempty"`
+	OutNotTaken *encodedFact `json:"outNotTaken,omitempty"`
+	OutTaken    *encodedFact `json:"outTaken,omitempty"`
+}
+
+type resultJSON struct {
+	Nodes       []resultNode `json:"nodes"`
+	Unreachable []int        `json:"unreachable"`
+	Stats       *Stats       `json:"stats,omitempty"`
+}
+
+// MarshalJSON encodes the facts of every node by label, using the registered fact codecs
+func (r *Result) MarshalJSON() ([]byte, error) {
+	labels := make(map[int]bool)
+	for _, facts := range []map[int]Fact{r.In, r.OutNotTaken, r.OutTaken} {
+		for label := range facts {
+			labels[label] = true
+		}
+	}
+	sorted := make([]int, 0, len(labels))
+	for label := range labels {
+		sorted = append(sorted, label)
+	}
+	sort.Ints(sorted)
+
+	out := resultJSON{Nodes: make([]resultNode, len(sorted)), Unreachable: r.Unreachable, Stats: r.Stats}
+	if out.Unreachable == nil {
+		out.Unreachable = []int{}
+	}
+	for i, label := range sorted {
+		n := resultNode{Label: label}
+		var err error
+		if n.In, err = encodeFact(r.In[label]); err != nil {
+			return nil, err
+		}
+		if n.OutNotTaken, err = encodeFact(r.OutNotTaken[label]); err != nil {
+			return nil, err
+		}
+		if n.OutTaken, err = encodeFact(r.OutTaken[label]); err != nil {
+			return nil, err
+		}
+		out.Nodes[i] = n
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a Result encoded by MarshalJSON, facts without a registered codec become TextFacts
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var in resultJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	r.In = make(map[int]Fact, len(in.Nodes))
+	r.OutNotTaken = make(map[int]Fact, len(in.Nodes))
+	r.OutTaken = nil
+	r.Unreachable = in.Unreachable
+	r.Stats = in.Stats
+	for _, n := range in.Nodes {
+		facts := []struct {
+			e    *encodedFact
+			into *map[int]Fact
+		}{{n.In, &r.In}, {n.OutNotTaken, &r.OutNotTaken}, {n.OutTaken, &r.OutTaken}}
+		for _, f := range facts {
+			if f.e == nil {
+				continue
+			}
+			fact, err := decodeFact(f.e)
+			if err != nil {
+				return fmt.Errorf("node %d: %w", n.Label, err)
+			}
+			if *f.into == nil {
+				*f.into = make(map[int]Fact)
+			}
+			(*f.into)[n.Label] = fact
+		}
+	}
+	return nil
+}
+
+type graphNode struct {
+	Label    int    `json:"label"`
+	Stmt     string `json:"stmt"`
+	NotTaken []int  `json:"notTaken"`
+	Taken    []int  `json:"taken"`
+}
+
+type graphJSON struct {
+	Entries []int       `json:"entries"`
+	Nodes   []graphNode `json:"nodes"`
+}
+
+// EncodeGraph encodes the structure of the graph: its entries, and for every node its label, the text of its
+// statement and its typed successor edges
+func EncodeGraph(entryIds []int, ids []int, idToNode map[int]Node) ([]byte, error) {
+	out := graphJSON{Entries: append([]int{}, entryIds...), Nodes: make([]graphNode, 0, len(ids))}
+	sorted := append([]int{}, ids...)
+	sort.Ints(sorted)
+	for _, id := range sorted {
+		n := idToNode[id]
+		stmt := ""
+		if s := n.Get(); s != nil {
+			stmt = fmt.Sprint(s)
+		}
+		out.Nodes = append(out.Nodes, graphNode{
+			Label:    id,
+			Stmt:     stmt,
+			NotTaken: append([]int{}, n.SuccsNotTaken()...),
+			Taken:    append([]int{}, n.SuccsTaken()...),
+		})
+	}
+	return json.Marshal(out)
+}
+
+// EncodeGraphPI encodes the structure of the path-insensitive graph, see EncodeGraph
+func EncodeGraphPI(entryIds []int, ids []int, idToNode map[int]NodePI) ([]byte, error) {
+	return EncodeGraph(entryIds, ids, wrapPI(idToNode))
+}
+
+// DecodeGraph decodes a graph encoded by EncodeGraph. The nodes are GraphNodes, their statements are the text of
+// the original statements.
+func DecodeGraph(data []byte) (entryIds []int, ids []int, idToNode map[int]Node, err error) {
+	var in graphJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, nil, nil, err
+	}
+
+	g := NewGraph()
+	ids = make([]int, 0, len(in.Nodes))
+	for _, n := range in.Nodes {
+		if g.Node(n.Label) != nil {
+			return nil, nil, nil, fmt.Errorf("duplicate node %d", n.Label)
+		}
+		g.AddNode(n.Label, n.Stmt)
+		ids = append(ids, n.Label)
+	}
+	for _, n := range in.Nodes {
+		for _, succs := range []struct {
+			labels []int
+			taken  bool
+		}{{n.NotTaken, false}, {n.Taken, true}} {
+			for _, succ := range succs.labels {
+				if g.Node(succ) == nil {
+					return nil, nil, nil, fmt.Errorf("edge from %d to unknown node %d", n.Label, succ)
+				}
+				g.AddEdge(n.Label, succ, succs.taken)
+			}
+		}
+	}
+	g.Entries = in.Entries
+
+	return g.Entries, ids, g.Nodes(), nil
+}
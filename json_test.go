@@ -0,0 +1,188 @@
+package dataflowanalysis_test
+
+import (
+	"encoding/json"
+	dfa "github.com/skius/dataflowanalysis"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// counterCodec encodes counters as JSON strings, to tell its encoding from the one of encoding/json
+type counterCodec struct{}
+
+func (counterCodec) Encode(f dfa.Fact) (json.RawMessage, error) {
+	return json.Marshal(f.String())
+}
+
+func (counterCodec) Decode(data json.RawMessage) (dfa.Fact, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(s)
+	return counter(n), err
+}
+
+// A word is a Fact whose type is never registered
+type word string
+
+func (w word) Equals(f dfa.Fact) bool {
+	return w == f.(word)
+}
+
+func (w word) String() string {
+	return "word " + string(w)
+}
+
+func init() {
+	// The registry is global, registering in a test would panic when it runs again
+	dfa.RegisterFact("test.mask", mask(0))
+	dfa.RegisterFactCodec("test.counter", counter(0), counterCodec{})
+}
+
+// roundTrip encodes and decodes the result
+func roundTrip(t *testing.T, r *dfa.Result) (*dfa.Result, string) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	decoded := new(dfa.Result)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return decoded, string(data)
+}
+
+func TestResultJSON(t *testing.T) {
+	// 5 is unreachable
+	g := dfa.MustParseGraph("entry 1; 1 -> 2 -> 3 -> 2; 2 -T-> 4; 5 -> 4")
+	st := new(dfa.Stats)
+	in, outNotTaken, outTaken := dfa.RunForward(g.Entries, g.IDs(), g.Nodes(), visited.Merge, visited.Flow,
+		visited.Initial, visited.Entry, dfa.WithStats(st))
+	r := dfa.NewResult(g.Entries, g.IDs(), g.Nodes(), in, outNotTaken, outTaken, st)
+
+	decoded, _ := roundTrip(t, r)
+	if !reflect.DeepEqual(decoded, r) {
+		t.Errorf("decoded %+v, want %+v", decoded, r)
+	}
+	if !reflect.DeepEqual(decoded.Unreachable, []int{5}) {
+		t.Errorf("Unreachable = %v, want [5]", decoded.Unreachable)
+	}
+}
+
+func TestResultJSONCodec(t *testing.T) {
+	g := dfa.MustParseGraph("entry 1; 1 -> 2 -> 3")
+	in, out := dfa.RunForwardPI(g.Entries, g.IDs(), g.NodesPI(), counterMax, counterFlow, counter(0), counter(0))
+	r := &dfa.Result{In: in, OutNotTaken: out}
+
+	decoded, data := roundTrip(t, r)
+	if !strings.Contains(data, `"type":"test.counter","value":"3"`) {
+		t.Errorf("the out fact of 3 is not encoded by its codec: %s", data)
+	}
+	// Path-insensitive results have no taken out facts and no unreachable nodes
+	want := &dfa.Result{In: in, OutNotTaken: out, Unreachable: []int{}}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("decoded %+v, want %+v", decoded, want)
+	}
+}
+
+func TestResultJSONUnregistered(t *testing.T) {
+	r := &dfa.Result{
+		In:          map[int]dfa.Fact{1: word("a"), 2: maskOf(1)},
+		OutNotTaken: map[int]dfa.Fact{1: word("b")},
+	}
+
+	decoded, _ := roundTrip(t, r)
+	want := &dfa.Result{
+		In:          map[int]dfa.Fact{1: dfa.TextFact("word a"), 2: maskOf(1)},
+		OutNotTaken: map[int]dfa.Fact{1: dfa.TextFact("word b")},
+		Unreachable: []int{},
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("decoded %+v, want %+v", decoded, want)
+	}
+}
+
+func TestUnmarshalFact(t *testing.T) {
+	tests := []struct {
+		data string
+		want dfa.Fact
+		err  string
+	}{
+		{data: `{"type":"test.mask","value":6,"text":"0x6"}`, want: maskOf(1, 2)},
+		{data: `{"text":"0x6"}`, want: dfa.TextFact("0x6")},
+		{data: `{"type":"test.unknown","value":6,"text":"0x6"}`, err: `no fact codec registered for "test.unknown"`},
+		{data: `{"type":"test.counter","value":3,"text":"3"}`, err: "decoding test.counter fact"},
+	}
+	for _, tt := range tests {
+		got, err := dfa.UnmarshalFact([]byte(tt.data))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("UnmarshalFact(%s) error = %v, want %q", tt.data, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("UnmarshalFact(%s) = %v, %v, want %v", tt.data, got, err, tt.want)
+		}
+	}
+}
+
+func TestGraphJSON(t *testing.T) {
+	g := dfa.MustParseGraph(`entry 1; 1 -> 2 -> 3 -> 2; 2 -T-> 4; 5 -> 4
+		1 "x = 1"; 2 "if x"; 4 "print \"x\""`)
+	data, err := dfa.EncodeGraph(g.Entries, g.IDs(), g.Nodes())
+	if err != nil {
+		t.Fatalf("EncodeGraph: %v", err)
+	}
+	entries, ids, nodes, err := dfa.DecodeGraph(data)
+	if err != nil {
+		t.Fatalf("DecodeGraph: %v", err)
+	}
+
+	if !reflect.DeepEqual(entries, g.Entries) || !reflect.DeepEqual(sorted(ids), g.IDs()) {
+		t.Errorf("decoded entries %v and ids %v, want %v and %v", entries, ids, g.Entries, g.IDs())
+	}
+	for _, id := range g.IDs() {
+		n, want := nodes[id], g.Node(id)
+		// The statements decode as their text, an empty one if there was none
+		stmt, _ := want.Get().(string)
+		if n.Get() != stmt {
+			t.Errorf("statement of %d = %q, want %q", id, n.Get(), stmt)
+		}
+		if !reflect.DeepEqual(n.SuccsNotTaken(), want.SuccsNotTaken()) ||
+			!reflect.DeepEqual(n.SuccsTaken(), want.SuccsTaken()) ||
+			!reflect.DeepEqual(n.PredsNotTaken(), want.PredsNotTaken()) ||
+			!reflect.DeepEqual(n.PredsTaken(), want.PredsTaken()) {
+			t.Errorf("edges of %d differ", id)
+		}
+	}
+
+	again, err := dfa.EncodeGraph(entries, ids, nodes)
+	if err != nil || string(again) != string(data) {
+		t.Errorf("encoding the decoded graph gives %s, %v, want %s", again, err, data)
+	}
+}
+
+func TestDecodeGraphErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{
+			data: `{"entries":[1],"nodes":[{"label":1,"stmt":"","notTaken":[],"taken":[]},{"label":1,"stmt":"","notTaken":[],"taken":[]}]}`,
+			err:  "duplicate node 1",
+		},
+		{
+			data: `{"entries":[1],"nodes":[{"label":1,"stmt":"","notTaken":[],"taken":[2]}]}`,
+			err:  "edge from 1 to unknown node 2",
+		},
+	}
+	for _, tt := range tests {
+		if _, _, _, err := dfa.DecodeGraph([]byte(tt.data)); err == nil || err.Error() != tt.err {
+			t.Errorf("DecodeGraph error = %v, want %q", err, tt.err)
+		}
+	}
+}
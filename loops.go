@@ -0,0 +1,290 @@
+package dataflowanalysis
+
+import "sort"
+
+// An Edge is a directed edge between two nodes
+type Edge struct {
+	From, To int
+}
+
+// A Loop is a natural loop, i.e. the nodes that can reach one of its back edges without passing through the header
+type Loop struct {
+	Header    int
+	Body      []int  // All nodes of the loop, including the header and the bodies of nested loops, sorted
+	BackEdges []Edge // The back edges into Header
+	Exits     []Edge // The edges leaving the loop
+	Parent    *Loop  // The innermost loop enclosing this loop, nil for outermost loops
+	Children  []*Loop
+	Depth     int // The nesting depth, 1 for outermost loops
+
+	body map[int]bool
+}
+
+// Contains returns whether the node is part of the loop
+func (l *Loop) Contains(id int) bool {
+	return l.body[id]
+}
+
+// LoopInfo is the loop structure of a graph, relative to a set of entries
+type LoopInfo struct {
+	SCCs      [][]int // The strongly connected components of the whole graph, in reverse topological order
+	BackEdges []Edge  // The retreating edges of a depth-first search from the entries
+	Loops     []*Loop // The natural loops, sorted by header
+	Roots     []*Loop // The outermost loops, i.e. the roots of the loop nesting forest
+
+	// Irreducible is set if some retreating edge's target does not dominate its source, such a cycle has more than
+	// one entry and is not the natural loop of any header
+	Irreducible        bool
+	IrreducibleEdges   []Edge  // The retreating edges that are not back edges of a natural loop
+	IrreducibleRegions [][]int // The strongly connected components containing an IrreducibleEdge
+
+	innermost map[int]*Loop
+}
+
+// LoopOf returns the innermost loop containing the node, or nil
+func (li *LoopInfo) LoopOf(id int) *Loop {
+	return li.innermost[id]
+}
+
+// Depth returns the number of loops containing the node
+func (li *LoopInfo) Depth(id int) int {
+	if l := li.innermost[id]; l != nil {
+		return l.Depth
+	}
+	return 0
+}
+
+// FindLoops computes the loop structure of the part of the graph reachable from the nodes in entryIds
+func FindLoops(entryIds []int, ids []int, idToNode map[int]Node) *LoopInfo {
+	v := newView(ids, idToNode)
+	dom := newDomTree(v, entryIds)
+	li := new(LoopInfo)
+
+	for _, scc := range tarjan(v) {
+		li.SCCs = append(li.SCCs, v.labelsOf(scc))
+	}
+
+	// Retreating edges are those pointing to a node on the DFS stack
+	onStack := make([]bool, len(v.labels))
+	visited := make([]bool, len(v.labels))
+	var dfs func(int)
+	dfs = func(i int) {
+		visited[i] = true
+		onStack[i] = true
+		for _, s := range v.succs[i] {
+			if onStack[s] {
+				li.BackEdges = append(li.BackEdges, Edge{From: v.labels[i], To: v.labels[s]})
+			} else if !visited[s] {
+				dfs(s)
+			}
+		}
+		onStack[i] = false
+	}
+	for _, i := range v.indicesOf(entryIds) {
+		if !visited[i] {
+			dfs(i)
+		}
+	}
+	sortEdges(li.BackEdges)
+
+	headerToLoop := make(map[int]*Loop)
+	for _, e := range li.BackEdges {
+		if !dom.Dominates(e.To, e.From) {
+			li.Irreducible = true
+			li.IrreducibleEdges = append(li.IrreducibleEdges, e)
+			continue
+		}
+
+		l, ok := headerToLoop[e.To]
+		if !ok {
+			l = &Loop{Header: e.To, body: map[int]bool{e.To: true}}
+			headerToLoop[e.To] = l
+			li.Loops = append(li.Loops, l)
+		}
+		l.BackEdges = append(l.BackEdges, e)
+
+		// Walk backwards from the latch until reaching the header
+		work := []int{e.From}
+		for len(work) > 0 {
+			curr := work[len(work)-1]
+			work = work[:len(work)-1]
+			if l.body[curr] {
+				continue
+			}
+			l.body[curr] = true
+			for _, p := range v.preds[v.index[curr]] {
+				if dom.Reachable(v.labels[p]) {
+					work = append(work, v.labels[p])
+				}
+			}
+		}
+	}
+
+	for _, l := range li.Loops {
+		for id := range l.body {
+			l.Body = append(l.Body, id)
+			for _, s := range v.succs[v.index[id]] {
+				if !l.body[v.labels[s]] {
+					l.Exits = append(l.Exits, Edge{From: id, To: v.labels[s]})
+				}
+			}
+		}
+		sort.Ints(l.Body)
+		sortEdges(l.Exits)
+	}
+
+	// Natural loops are either disjoint or nested, the parent of a loop is the smallest other loop containing its
+	// header
+	sort.Slice(li.Loops, func(i, j int) bool {
+		return len(li.Loops[i].Body) < len(li.Loops[j].Body)
+	})
+	li.innermost = make(map[int]*Loop)
+	for i, l := range li.Loops {
+		for _, id := range l.Body {
+			if _, ok := li.innermost[id]; !ok {
+				li.innermost[id] = l
+			}
+		}
+		for _, outer := range li.Loops[i+1:] {
+			if outer.Contains(l.Header) {
+				l.Parent = outer
+				outer.Children = append(outer.Children, l)
+				break
+			}
+		}
+	}
+
+	sort.Slice(li.Loops, func(i, j int) bool {
+		return li.Loops[i].Header < li.Loops[j].Header
+	})
+	for _, l := range li.Loops {
+		sort.Slice(l.Children, func(i, j int) bool {
+			return l.Children[i].Header < l.Children[j].Header
+		})
+		if l.Parent == nil {
+			li.Roots = append(li.Roots, l)
+		}
+	}
+	var setDepth func(*Loop, int)
+	setDepth = func(l *Loop, depth int) {
+		l.Depth = depth
+		for _, c := range l.Children {
+			setDepth(c, depth+1)
+		}
+	}
+	for _, l := range li.Roots {
+		setDepth(l, 1)
+	}
+
+	for _, scc := range li.SCCs {
+		for _, e := range li.IrreducibleEdges {
+			if containsInt(scc, e.From) {
+				li.IrreducibleRegions = append(li.IrreducibleRegions, scc)
+				break
+			}
+		}
+	}
+
+	return li
+}
+
+// FindLoopsPI computes the loop structure of the part of the path-insensitive graph reachable from the nodes in
+// entryIds
+func FindLoopsPI(entryIds []int, ids []int, idToNode map[int]NodePI) *LoopInfo {
+	return FindLoops(entryIds, ids, wrapPI(idToNode))
+}
+
+// StronglyConnectedComponents returns the strongly connected components of the graph in reverse topological order,
+// i.e. every component comes before the components that can reach it
+func StronglyConnectedComponents(ids []int, idToNode map[int]Node) [][]int {
+	v := newView(ids, idToNode)
+	sccs := make([][]int, 0)
+	for _, scc := range tarjan(v) {
+		sccs = append(sccs, v.labelsOf(scc))
+	}
+	return sccs
+}
+
+// StronglyConnectedComponentsPI returns the strongly connected components of the path-insensitive graph in reverse
+// topological order
+func StronglyConnectedComponentsPI(ids []int, idToNode map[int]NodePI) [][]int {
+	return StronglyConnectedComponents(ids, wrapPI(idToNode))
+}
+
+// tarjan runs Tarjan's algorithm, returning the components as indices in reverse topological order
+func tarjan(v *view) [][]int {
+	n := len(v.labels)
+	index := make([]int, n)
+	lowlink := make([]int, n)
+	onStack := make([]bool, n)
+	for i := range index {
+		index[i] = -1
+	}
+	stack := make([]int, 0)
+	sccs := make([][]int, 0)
+	ctr := 0
+
+	var strongConnect func(int)
+	strongConnect = func(i int) {
+		index[i] = ctr
+		lowlink[i] = ctr
+		ctr++
+		stack = append(stack, i)
+		onStack[i] = true
+
+		for _, s := range v.succs[i] {
+			if index[s] == -1 {
+				strongConnect(s)
+				lowlink[i] = minInt(lowlink[i], lowlink[s])
+			} else if onStack[s] {
+				lowlink[i] = minInt(lowlink[i], index[s])
+			}
+		}
+
+		if lowlink[i] == index[i] {
+			scc := make([]int, 0)
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				scc = append(scc, top)
+				if top == i {
+					break
+				}
+			}
+			sccs = append(sccs, scc)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if index[i] == -1 {
+			strongConnect(i)
+		}
+	}
+	return sccs
+}
+
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+}
+
+func containsInt(xs []int, x int) bool {
+	for _, y := range xs {
+		if y == x {
+			return true
+		}
+	}
+	return false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
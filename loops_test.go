@@ -0,0 +1,149 @@
+package dataflowanalysis_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"reflect"
+	"testing"
+)
+
+// A wantLoop describes an expected natural loop, parent is the header of the enclosing loop, 0 for outermost loops
+type wantLoop struct {
+	header    int
+	body      []int
+	backEdges []dfa.Edge
+	exits     []dfa.Edge
+	parent    int
+	depth     int
+}
+
+func TestFindLoops(t *testing.T) {
+	tests := []struct {
+		name        string
+		graph       string
+		loops       []wantLoop // Sorted by header
+		depths      map[int]int
+		irreducible []dfa.Edge
+		regions     [][]int
+	}{
+		{
+			name:   "no loop",
+			graph:  "entry 1; 1 -> 2 -> 4; 1 -T-> 3 -> 4",
+			depths: map[int]int{1: 0, 2: 0, 3: 0, 4: 0},
+		},
+		{
+			name:  "self loop",
+			graph: "entry 1; 1 -> 2 -> 2; 2 -T-> 3",
+			loops: []wantLoop{
+				{header: 2, body: []int{2}, backEdges: []dfa.Edge{{From: 2, To: 2}}, exits: []dfa.Edge{{From: 2, To: 3}}, depth: 1},
+			},
+			depths: map[int]int{1: 0, 2: 1, 3: 0},
+		},
+		{
+			name:  "while loop",
+			graph: "entry 1; 1 -> 2 -> 3 -> 2; 2 -T-> 4",
+			loops: []wantLoop{
+				{header: 2, body: []int{2, 3}, backEdges: []dfa.Edge{{From: 3, To: 2}}, exits: []dfa.Edge{{From: 2, To: 4}}, depth: 1},
+			},
+			depths: map[int]int{1: 0, 2: 1, 3: 1, 4: 0},
+		},
+		{
+			name:  "two back edges",
+			graph: "entry 1; 1 -> 2 -> 3 -> 2; 3 -T-> 4 -> 2; 2 -T-> 5",
+			loops: []wantLoop{
+				{
+					header:    2,
+					body:      []int{2, 3, 4},
+					backEdges: []dfa.Edge{{From: 3, To: 2}, {From: 4, To: 2}},
+					exits:     []dfa.Edge{{From: 2, To: 5}},
+					depth:     1,
+				},
+			},
+			depths: map[int]int{2: 1, 3: 1, 4: 1, 5: 0},
+		},
+		{
+			name:  "nested loops",
+			graph: "entry 1; 1 -> 2 -> 3 -> 4 -> 3; 4 -T-> 5 -> 2; 2 -T-> 6",
+			loops: []wantLoop{
+				{header: 2, body: []int{2, 3, 4, 5}, backEdges: []dfa.Edge{{From: 5, To: 2}}, exits: []dfa.Edge{{From: 2, To: 6}}, depth: 1},
+				{header: 3, body: []int{3, 4}, backEdges: []dfa.Edge{{From: 4, To: 3}}, exits: []dfa.Edge{{From: 4, To: 5}}, parent: 2, depth: 2},
+			},
+			depths: map[int]int{1: 0, 2: 1, 3: 2, 4: 2, 5: 1, 6: 0},
+		},
+		{
+			name:        "irreducible cycle",
+			graph:       "entry 1; 1 -> 2 -> 3 -> 2; 1 -T-> 3; 3 -T-> 4",
+			depths:      map[int]int{2: 0, 3: 0},
+			irreducible: []dfa.Edge{{From: 3, To: 2}},
+			regions:     [][]int{{2, 3}},
+		},
+		{
+			name:   "unreachable loop",
+			graph:  "entry 1; 1 -> 2; 3 -> 4 -> 3",
+			depths: map[int]int{3: 0, 4: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := dfa.MustParseGraph(tt.graph)
+			li := dfa.FindLoops(g.Entries, g.IDs(), g.Nodes())
+
+			got := make([]wantLoop, 0, len(li.Loops))
+			for _, l := range li.Loops {
+				w := wantLoop{header: l.Header, body: l.Body, backEdges: l.BackEdges, exits: l.Exits, depth: l.Depth}
+				if l.Parent != nil {
+					w.parent = l.Parent.Header
+				}
+				got = append(got, w)
+			}
+			if len(got) != len(tt.loops) || len(got) > 0 && !reflect.DeepEqual(got, tt.loops) {
+				t.Errorf("loops = %+v, want %+v", got, tt.loops)
+			}
+			for id, want := range tt.depths {
+				if d := li.Depth(id); d != want {
+					t.Errorf("Depth(%d) = %d, want %d", id, d, want)
+				}
+			}
+
+			if li.Irreducible != (len(tt.irreducible) > 0) {
+				t.Errorf("Irreducible = %v", li.Irreducible)
+			}
+			if len(li.IrreducibleEdges) != len(tt.irreducible) ||
+				len(tt.irreducible) > 0 && !reflect.DeepEqual(li.IrreducibleEdges, tt.irreducible) {
+				t.Errorf("IrreducibleEdges = %v, want %v", li.IrreducibleEdges, tt.irreducible)
+			}
+			regions := make([][]int, 0)
+			for _, r := range li.IrreducibleRegions {
+				regions = append(regions, sorted(r))
+			}
+			if len(regions) != len(tt.regions) || len(regions) > 0 && !reflect.DeepEqual(regions, tt.regions) {
+				t.Errorf("IrreducibleRegions = %v, want %v", regions, tt.regions)
+			}
+		})
+	}
+}
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	// {1}, {2 3 4} and {5 6}, in reverse topological order: {5 6} and {2 3 4} come before 1, which reaches them
+	g := dfa.MustParseGraph("entry 1; 1 -> 2 -> 3 -> 4 -> 2; 1 -T-> 5 -> 6 -> 5")
+	sccs := dfa.StronglyConnectedComponents(g.IDs(), g.Nodes())
+	if len(sccs) != 3 {
+		t.Fatalf("got %d components %v, want 3", len(sccs), sccs)
+	}
+	position := make(map[int]int)
+	for i, scc := range sccs {
+		for _, id := range scc {
+			position[id] = i
+		}
+	}
+	for _, same := range [][]int{{2, 3, 4}, {5, 6}} {
+		for _, id := range same[1:] {
+			if position[id] != position[same[0]] {
+				t.Errorf("%d and %d are in different components %v", id, same[0], sccs)
+			}
+		}
+	}
+	if position[1] != 2 {
+		t.Errorf("the component of the entry is at %d, want it last in %v", position[1], sccs)
+	}
+}
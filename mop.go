@@ -0,0 +1,128 @@
+package dataflowanalysis
+
+// RunMOP computes the meet over all paths solution of a path-sensitive forward data-flow analysis, the reference the
+// fixpoint computed by RunForward approximates. It enumerates every path from an entry, flowing entryFlow along it,
+// and merges the facts reaching each node over all paths. Nodes reached by no path have initialFlow, and a nil out
+// fact continues the path with initialFlow, as in RunForward.
+//
+// Cyclic graphs have infinitely many paths, so each node appears at most unroll+1 times on a path, i.e. every loop is
+// unrolled unroll times. The result then only merges a subset of the paths, so it is at most as conservative as the
+// true meet over all paths. Nodes unreachable from the entries contribute nothing, whereas RunForward flows their
+// facts into their successors.
+//
+// The number of paths is exponential in the size of the graph, so this is only meant for testing on small graphs.
+func RunMOP(
+	entryIds []int,
+	ids []int,
+	idToNode map[int]Node,
+	merge func(Fact, Fact) Fact, // Meet operator
+	flow func(Fact, Node) (Fact, Fact), // Flow function
+	initialFlow Fact,
+	entryFlow Fact,
+	unroll int,
+) (in map[int]Fact) {
+	in = make(map[int]Fact, len(ids))
+	visits := make(map[int]int, len(ids))
+
+	var walk func(id int, fact Fact)
+	walk = func(id int, fact Fact) {
+		if visits[id] > unroll {
+			return
+		}
+		visits[id]++
+		defer func() { visits[id]-- }()
+
+		if prev, ok := in[id]; ok {
+			in[id] = merge(prev, fact)
+		} else {
+			in[id] = fact
+		}
+
+		n := idToNode[id]
+		outNotTaken, outTaken := flow(fact, n)
+		if outNotTaken == nil {
+			outNotTaken = initialFlow
+		}
+		if outTaken == nil {
+			outTaken = initialFlow
+		}
+		for _, succ := range n.SuccsNotTaken() {
+			walk(succ, outNotTaken)
+		}
+		for _, succ := range n.SuccsTaken() {
+			walk(succ, outTaken)
+		}
+	}
+
+	for _, id := range entryIds {
+		walk(id, entryFlow)
+	}
+
+	for _, id := range ids {
+		if _, ok := in[id]; !ok {
+			in[id] = initialFlow
+		}
+	}
+	return in
+}
+
+// RunMOPPI computes the meet over all paths solution of a path-insensitive forward data-flow analysis, see RunMOP
+func RunMOPPI(
+	entryIds []int,
+	ids []int,
+	idToNode map[int]NodePI,
+	merge func(Fact, Fact) Fact, // Meet operator
+	flow func(Fact, NodePI) Fact, // Flow function
+	initialFlow Fact,
+	entryFlow Fact,
+	unroll int,
+) (in map[int]Fact) {
+	flowWrapper := func(f Fact, n Node) (Fact, Fact) {
+		return flow(f, n.(*piToPSWrapper).actualNode), nil
+	}
+	return RunMOP(entryIds, ids, wrapPI(idToNode), merge, flowWrapper, initialFlow, entryFlow, unroll)
+}
+
+// A Precision compares a fact to a reference fact, in the order where merging makes facts less precise
+type Precision int
+
+const (
+	Equal        Precision = iota // The facts are equal
+	LessPrecise                   // The fact is more conservative than the reference
+	MorePrecise                   // The fact is less conservative than the reference
+	Incomparable                  // Neither fact is more conservative than the other
+)
+
+func (p Precision) String() string {
+	switch p {
+	case Equal:
+		return "Equal"
+	case LessPrecise:
+		return "LessPrecise"
+	case MorePrecise:
+		return "MorePrecise"
+	}
+	return "Incomparable"
+}
+
+// ComparePrecision compares the fact of every node to the reference, e.g. the in facts of RunForward to those of
+// RunMOP. A fact is less precise than the reference if merging the reference into it does not change it.
+// As the fixpoint always approximates the meet over all paths, MorePrecise or Incomparable against RunMOP indicate a
+// bug in the analysis, e.g. a flow function that is not monotone.
+func ComparePrecision(ids []int, facts, reference map[int]Fact, merge func(Fact, Fact) Fact) map[int]Precision {
+	res := make(map[int]Precision, len(ids))
+	for _, id := range ids {
+		f, ref := facts[id], reference[id]
+		switch {
+		case f.Equals(ref):
+			res[id] = Equal
+		case merge(ref, f).Equals(f):
+			res[id] = LessPrecise
+		case merge(f, ref).Equals(ref):
+			res[id] = MorePrecise
+		default:
+			res[id] = Incomparable
+		}
+	}
+	return res
+}
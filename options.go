@@ -0,0 +1,71 @@
+package dataflowanalysis
+
+import "context"
+
+// An Option configures a run of a solver
+type Option func(*config)
+
+// config holds the settings of a solver run
+type config struct {
+	provenance *Provenance
+	history    *History
+	stepHook   func(*StepEvent)
+	table      *IterationTable
+	stats      *Stats
+	timing     bool            // Whether the stats include the time spent in the callbacks
+	profile    context.Context // The context to add pprof labels to, nil if no labels are requested
+	backward   bool            // Set by the backward solvers, which run forward on the reversed graph
+}
+
+func newConfig(opts []Option) *config {
+	c := new(config)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithProvenance records into p where the facts of the run come from, see Provenance
+func WithProvenance(p *Provenance) Option {
+	return func(c *config) {
+		c.provenance = p
+	}
+}
+
+// WithHistory records into h the facts of every node after every step of the run, see History
+func WithHistory(h *History) Option {
+	return func(c *config) {
+		c.history = h
+	}
+}
+
+// WithStepHook calls f after every step of the run, i.e. after every evaluation of the flow function. f may block,
+// e.g. to let a user step through the run. With a step hook, RunForward pops its worklist in label order, so runs
+// are reproducible and the node evaluated next is the first of StepEvent.Worklist.
+func WithStepHook(f func(*StepEvent)) Option {
+	return func(c *config) {
+		c.stepHook = f
+	}
+}
+
+// A StepEvent describes a step of a solver run. For backward analyses the facts are the in and out facts of the
+// original graph, the out fact as OutNotTaken, so In is the fact computed by the step and OutNotTaken the merged one.
+type StepEvent struct {
+	Step            int // The number of the step, counting from 1
+	Label           int
+	Stmt            Stmt
+	In              Fact // The merged fact the node was evaluated with
+	OutNotTaken     Fact
+	OutTaken        Fact
+	ChangedIn       bool  // Whether the step changed In
+	ChangedNotTaken bool  // Whether the step changed OutNotTaken
+	ChangedTaken    bool  // Whether the step changed OutTaken
+	Worklist        []int // The nodes left to evaluate after the step, sorted, nil if the solver has no worklist
+}
+
+// reversed marks a run as solving a backward analysis on the reversed graph
+func reversed() Option {
+	return func(c *config) {
+		c.backward = true
+	}
+}
@@ -0,0 +1,203 @@
+package dataflowanalysis
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Partition is a disjunct of a DisjunctiveFact: the fact of the traces with the same key
+type Partition struct {
+	Key  string
+	Fact Fact
+}
+
+// A DisjunctiveFact is a Fact of a disjunctive analysis, a set of facts of an underlying analysis, one per partition
+// of the traces reaching a node, sorted by key. It has no partitions if no trace reaches the node.
+type DisjunctiveFact struct {
+	Level      int // The collapse level of the keys, it only grows along the traces
+	Partitions []Partition
+}
+
+func (d DisjunctiveFact) Equals(other Fact) bool {
+	o := other.(DisjunctiveFact)
+	if d.Level != o.Level || len(d.Partitions) != len(o.Partitions) {
+		return false
+	}
+	for i := range d.Partitions {
+		if d.Partitions[i].Key != o.Partitions[i].Key || !d.Partitions[i].Fact.Equals(o.Partitions[i].Fact) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d DisjunctiveFact) String() string {
+	parts := make([]string, len(d.Partitions))
+	for i, p := range d.Partitions {
+		parts[i] = "[" + p.Key + "] " + p.Fact.String()
+	}
+	s := "{ " + strings.Join(parts, " | ") + " }"
+	if d.Level > 0 {
+		s = "L" + strconv.Itoa(d.Level) + " " + s
+	}
+	return s
+}
+
+// Merged merges the facts of all partitions into one fact of the underlying analysis, initial if there are none
+func (d DisjunctiveFact) Merged(merge func(Fact, Fact) Fact, initial Fact) Fact {
+	facts := make([]Fact, len(d.Partitions))
+	for i, p := range d.Partitions {
+		facts[i] = p.Fact
+	}
+	return mergeAll(merge, facts, initial)
+}
+
+// A PartitionKey returns the key of the traces leaving the node along its taken or not-taken edges, given the key of
+// the partition they belong to and the out fact of the underlying flow function
+type PartitionKey func(key string, out Fact, n Node, taken bool) string
+
+// BranchHistory partitions traces by the outcomes of the last depth branches they took, e.g. "5T 9F". Nodes without
+// taken successors are not branches and keep the key.
+func BranchHistory(depth int) PartitionKey {
+	return func(key string, _ Fact, n Node, taken bool) string {
+		if len(n.SuccsTaken()) == 0 {
+			return key
+		}
+		history := append(strings.Fields(key), ControlDep{Label: n.Label(), Taken: taken}.String())
+		return lastWords(history, depth)
+	}
+}
+
+// A CollapsePolicy coarsens a key to a collapse level. Level 0 keeps keys unchanged, every further level must merge
+// at least the keys the previous one merges, i.e. collapsing to a level and then to a higher one equals collapsing to
+// the higher one directly, and some level should merge all keys. Disjunctive merges all keys beyond level
+// maxCollapseLevel, whatever the policy.
+type CollapsePolicy func(key string, level int) string
+
+// maxCollapseLevel is the last level at which Disjunctive asks the CollapsePolicy, so that merging terminates even
+// if the policy never merges all keys
+const maxCollapseLevel = 64
+
+// CollapseAll merges all partitions into a single one with the empty key at level 1
+func CollapseAll(key string, level int) string {
+	if level == 0 {
+		return key
+	}
+	return ""
+}
+
+// CollapseOldest coarsens the keys of BranchHistory(depth) by forgetting their oldest branch per level, i.e. keeps the
+// outcomes of the last depth - level branches
+func CollapseOldest(depth int) CollapsePolicy {
+	return func(key string, level int) string {
+		if level == 0 {
+			return key
+		}
+		return lastWords(strings.Fields(key), depth-level)
+	}
+}
+
+// Disjunctive lifts the analysis to its disjunctive completion bounded by max: instead of merging all facts at joins,
+// it keeps one fact per partition of the traces, where key decides the partition of the traces leaving each node.
+// Facts of traces in the same partition are merged, and whenever a join would leave more than max partitions, the
+// keys are collapsed to the next level until at most max are left. The facts are DisjunctiveFacts,
+// DisjunctiveFact.Merged recovers facts of the underlying analysis.
+//
+// The level of a fact only grows along the traces, so partitions once collapsed stay collapsed and, if collapse
+// commutes with key on collapsed keys as CollapseOldest(depth) does with BranchHistory(depth), the analysis terminates
+// whenever the underlying one does and key produces finitely many keys.
+func Disjunctive(base Analysis, key PartitionKey, max int, collapse CollapsePolicy) Analysis {
+	if max < 1 {
+		max = 1
+	}
+	policy := collapse
+	collapse = func(key string, level int) string {
+		if level > maxCollapseLevel {
+			return CollapseAll(key, level)
+		}
+		return policy(key, level)
+	}
+
+	merge := func(f1, f2 Fact) Fact {
+		d1, d2 := f1.(DisjunctiveFact), f2.(DisjunctiveFact)
+		level := d1.Level
+		if d2.Level > level {
+			level = d2.Level
+		}
+		for {
+			partitions := make(map[string]Fact, len(d1.Partitions)+len(d2.Partitions))
+			for _, d := range []DisjunctiveFact{d1, d2} {
+				for _, p := range d.Partitions {
+					addPartition(partitions, collapse(p.Key, level), p.Fact, base.Merge)
+				}
+			}
+			if len(partitions) <= max {
+				return DisjunctiveFact{Level: level, Partitions: sortedPartitions(partitions)}
+			}
+			level++
+		}
+	}
+
+	flow := func(f Fact, n Node) (Fact, Fact) {
+		d := f.(DisjunctiveFact)
+		notTaken, taken := make(map[string]Fact), make(map[string]Fact)
+		anyNotTaken, anyTaken := false, false
+		for _, p := range d.Partitions {
+			outNotTaken, outTaken := base.Flow(p.Fact, n)
+			if outNotTaken != nil {
+				addPartition(notTaken, collapse(key(p.Key, outNotTaken, n, false), d.Level), outNotTaken, base.Merge)
+				anyNotTaken = true
+			}
+			if outTaken != nil {
+				addPartition(taken, collapse(key(p.Key, outTaken, n, true), d.Level), outTaken, base.Merge)
+				anyTaken = true
+			}
+		}
+
+		var outNotTaken, outTaken Fact
+		if anyNotTaken || len(d.Partitions) == 0 {
+			outNotTaken = DisjunctiveFact{Level: d.Level, Partitions: sortedPartitions(notTaken)}
+		}
+		if anyTaken || len(d.Partitions) == 0 {
+			outTaken = DisjunctiveFact{Level: d.Level, Partitions: sortedPartitions(taken)}
+		}
+		return outNotTaken, outTaken
+	}
+
+	return Analysis{
+		Merge:   merge,
+		Flow:    flow,
+		Initial: DisjunctiveFact{},
+		Entry:   DisjunctiveFact{Partitions: []Partition{{Key: "", Fact: base.Entry}}},
+	}
+}
+
+// lastWords joins the last n words
+func lastWords(words []string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(words) > n {
+		words = words[len(words)-n:]
+	}
+	return strings.Join(words, " ")
+}
+
+func addPartition(partitions map[string]Fact, key string, f Fact, merge func(Fact, Fact) Fact) {
+	if existing, ok := partitions[key]; ok {
+		f = merge(existing, f)
+	}
+	partitions[key] = f
+}
+
+func sortedPartitions(partitions map[string]Fact) []Partition {
+	d := make([]Partition, 0, len(partitions))
+	for key, f := range partitions {
+		d = append(d, Partition{Key: key, Fact: f})
+	}
+	sort.Slice(d, func(i, j int) bool {
+		return d[i].Key < d[j].Key
+	})
+	return d
+}
@@ -0,0 +1,158 @@
+package dataflowanalysis_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"testing"
+)
+
+// visited is an analysis collecting the labels of the nodes on some path to a node
+var visited = dfa.Analysis{
+	Merge: maskUnion,
+	Flow: func(f dfa.Fact, n dfa.Node) (dfa.Fact, dfa.Fact) {
+		out := f.(mask) | maskOf(n.Label())
+		return out, out
+	},
+	Initial: mask(0),
+	Entry:   mask(0),
+}
+
+func TestDisjunctive(t *testing.T) {
+	const (
+		// 2 branches to 3 and 4, which join at 5
+		diamond = "entry 1; 1 -> 2 -> 3 -> 5; 2 -T-> 4 -> 5"
+		// Then 5 branches to 6 and 7, which join at 8
+		twoDiamonds = diamond + "; 5 -> 6 -> 8; 5 -T-> 7 -> 8"
+	)
+	never := func(key string, level int) string {
+		return key
+	}
+
+	tests := []struct {
+		name     string
+		graph    string
+		depth    int // Of BranchHistory
+		max      int
+		collapse dfa.CollapsePolicy
+		label    int
+		want     dfa.DisjunctiveFact // The in fact of the node
+	}{
+		{
+			name:     "both branches kept",
+			graph:    diamond,
+			depth:    2,
+			max:      2,
+			collapse: dfa.CollapseOldest(2),
+			label:    5,
+			want: dfa.DisjunctiveFact{Partitions: []dfa.Partition{
+				{Key: "2F", Fact: maskOf(1, 2, 3)},
+				{Key: "2T", Fact: maskOf(1, 2, 4)},
+			}},
+		},
+		{
+			// Forgetting the oldest branch leaves the keys apart, only level 2 merges them
+			name:     "collapsed to a single partition",
+			graph:    diamond,
+			depth:    2,
+			max:      1,
+			collapse: dfa.CollapseOldest(2),
+			label:    5,
+			want: dfa.DisjunctiveFact{Level: 2, Partitions: []dfa.Partition{
+				{Key: "", Fact: maskOf(1, 2, 3, 4)},
+			}},
+		},
+		{
+			name:     "oldest branch forgotten",
+			graph:    twoDiamonds,
+			depth:    2,
+			max:      2,
+			collapse: dfa.CollapseOldest(2),
+			label:    8,
+			want: dfa.DisjunctiveFact{Level: 1, Partitions: []dfa.Partition{
+				{Key: "5F", Fact: maskOf(1, 2, 3, 4, 5, 6)},
+				{Key: "5T", Fact: maskOf(1, 2, 3, 4, 5, 7)},
+			}},
+		},
+		{
+			name:     "all partitions within max",
+			graph:    twoDiamonds,
+			depth:    2,
+			max:      4,
+			collapse: dfa.CollapseOldest(2),
+			label:    8,
+			want: dfa.DisjunctiveFact{Partitions: []dfa.Partition{
+				{Key: "2F 5F", Fact: maskOf(1, 2, 3, 5, 6)},
+				{Key: "2F 5T", Fact: maskOf(1, 2, 3, 5, 7)},
+				{Key: "2T 5F", Fact: maskOf(1, 2, 4, 5, 6)},
+				{Key: "2T 5T", Fact: maskOf(1, 2, 4, 5, 7)},
+			}},
+		},
+		{
+			// A policy that never merges keys is overridden after the last collapse level
+			name:     "policy never merging",
+			graph:    diamond,
+			depth:    2,
+			max:      1,
+			collapse: never,
+			label:    5,
+			want: dfa.DisjunctiveFact{Level: 65, Partitions: []dfa.Partition{
+				{Key: "", Fact: maskOf(1, 2, 3, 4)},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := dfa.MustParseGraph(tt.graph)
+			a := dfa.Disjunctive(visited, dfa.BranchHistory(tt.depth), tt.max, tt.collapse)
+			in, _, _ := a.Run(g.Entries, g.IDs(), g.Nodes())
+			if got := in[tt.label]; !got.Equals(tt.want) {
+				t.Errorf("in(%d) = %v, want %v", tt.label, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisjunctiveLoop(t *testing.T) {
+	// Every iteration of the loop 2 3 (4) 5 adds branches to the history, which must collapse for the run to end
+	g := dfa.MustParseGraph("entry 1; 1 -> 2 -> 3 -> 5 -> 2; 3 -T-> 4 -> 5; 2 -T-> 6")
+	for _, max := range []int{1, 2, 3} {
+		a := dfa.Disjunctive(visited, dfa.BranchHistory(3), max, dfa.CollapseOldest(3))
+		in, _, _ := a.Run(g.Entries, g.IDs(), g.Nodes())
+
+		exit := in[6].(dfa.DisjunctiveFact)
+		if len(exit.Partitions) > max {
+			t.Errorf("max %d: %d partitions at the exit", max, len(exit.Partitions))
+		}
+		if merged := exit.Merged(maskUnion, mask(0)); !merged.Equals(maskOf(1, 2, 3, 4, 5)) {
+			t.Errorf("max %d: the exit merges to %v", max, merged)
+		}
+	}
+}
+
+func TestBranchHistory(t *testing.T) {
+	g := dfa.MustParseGraph("entry 1; 1 -> 2; 2 -T-> 3")
+	key := dfa.BranchHistory(2)
+	tests := []struct {
+		key   string
+		label int
+		taken bool
+		want  string
+	}{
+		{key: "", label: 1, want: ""}, // Not a branch
+		{key: "", label: 2, taken: true, want: "2T"},
+		{key: "5F", label: 2, want: "5F 2F"},
+		{key: "5F 7T", label: 2, taken: true, want: "7T 2T"},
+	}
+	for _, tt := range tests {
+		if got := key(tt.key, nil, g.Node(tt.label), tt.taken); got != tt.want {
+			t.Errorf("key(%q) at %d = %q, want %q", tt.key, tt.label, got, tt.want)
+		}
+	}
+
+	collapse := dfa.CollapseOldest(2)
+	for level, want := range []string{"5F 7T", "7T", ""} {
+		if got := collapse("5F 7T", level); got != want {
+			t.Errorf("CollapseOldest(2) at level %d = %q, want %q", level, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package dataflowanalysis
+
+import "strings"
+
+// An Analysis bundles the lattice and flow function of a path-sensitive forward data-flow analysis, e.g. to combine
+// several into one with Product
+type Analysis struct {
+	Merge   func(Fact, Fact) Fact         // Meet operator
+	Flow    func(Fact, Node) (Fact, Fact) // Flow function
+	Initial Fact
+	Entry   Fact
+}
+
+// Run solves the Analysis with RunForward
+func (a Analysis) Run(
+	entryIds []int,
+	ids []int,
+	idToNode map[int]Node,
+	opts ...Option,
+) (in, outNotTaken, outTaken map[int]Fact) {
+	return RunForward(entryIds, ids, idToNode, a.Merge, a.Flow, a.Initial, a.Entry, opts...)
+}
+
+// A ProductFact is a Fact of a product analysis, holding the facts of its components in order
+type ProductFact []Fact
+
+func (p ProductFact) Equals(other Fact) bool {
+	o := other.(ProductFact)
+	if len(p) != len(o) {
+		return false
+	}
+	for i := range p {
+		if !p[i].Equals(o[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p ProductFact) String() string {
+	facts := make([]string, len(p))
+	for i, f := range p {
+		facts[i] = f.String()
+	}
+	return "(" + strings.Join(facts, ", ") + ")"
+}
+
+// A Reduction refines the facts of the components of a product analysis using each other, e.g. a constant string
+// determines whether it is empty. It is called with each out fact of the flow function at the node and must return
+// a fact that is at least as precise, without modifying p.
+type Reduction func(p ProductFact, n Node) ProductFact
+
+// Product combines the analyses into one whose facts are ProductFacts, so that they are solved together in a single
+// pass. Merges are componentwise, and after every flow step reduce, if not nil, lets the components refine each
+// other, which makes it a reduced product.
+//
+// If the flow function of a component returns nil for an edge, its initial fact is used, unless all components
+// return nil, in which case the product returns nil and no fact flows along the edge. This differs from RunForward,
+// where nil keeps the previous out fact: the flow function of the product has no previous fact to keep, so a
+// component's nil stands for no trace along the edge, which its initial fact represents.
+func Product(reduce Reduction, components ...Analysis) Analysis {
+	initial := make(ProductFact, len(components))
+	entry := make(ProductFact, len(components))
+	for i, c := range components {
+		initial[i], entry[i] = c.Initial, c.Entry
+	}
+
+	merge := func(f1, f2 Fact) Fact {
+		p1, p2 := f1.(ProductFact), f2.(ProductFact)
+		res := make(ProductFact, len(components))
+		for i, c := range components {
+			res[i] = c.Merge(p1[i], p2[i])
+		}
+		return res
+	}
+
+	flow := func(f Fact, n Node) (Fact, Fact) {
+		p := f.(ProductFact)
+		notTaken := make(ProductFact, len(components))
+		taken := make(ProductFact, len(components))
+		anyNotTaken, anyTaken := false, false
+		for i, c := range components {
+			notTaken[i], taken[i] = c.Flow(p[i], n)
+			if notTaken[i] == nil {
+				notTaken[i] = c.Initial
+			} else {
+				anyNotTaken = true
+			}
+			if taken[i] == nil {
+				taken[i] = c.Initial
+			} else {
+				anyTaken = true
+			}
+		}
+
+		var outNotTaken, outTaken Fact
+		if anyNotTaken {
+			outNotTaken = notTaken
+			if reduce != nil {
+				outNotTaken = reduce(notTaken, n)
+			}
+		}
+		if anyTaken {
+			outTaken = taken
+			if reduce != nil {
+				outTaken = reduce(taken, n)
+			}
+		}
+		return outNotTaken, outTaken
+	}
+
+	return Analysis{Merge: merge, Flow: flow, Initial: initial, Entry: entry}
+}
@@ -0,0 +1,157 @@
+package dataflowanalysis_test
+
+import (
+	"fmt"
+	dfa "github.com/skius/dataflowanalysis"
+	"testing"
+)
+
+// A mask is a Fact holding a set of small integers, e.g. labels, as bits
+type mask uint64
+
+func (m mask) Equals(f dfa.Fact) bool {
+	return m == f.(mask)
+}
+
+func (m mask) String() string {
+	return fmt.Sprintf("%#x", uint64(m))
+}
+
+func maskUnion(a, b dfa.Fact) dfa.Fact {
+	return a.(mask) | b.(mask)
+}
+
+func maskOf(labels ...int) mask {
+	var m mask
+	for _, l := range labels {
+		m |= 1 << l
+	}
+	return m
+}
+
+// reduced is the bit the reduction of TestProduct sets
+const reduced = 63
+
+func TestProduct(t *testing.T) {
+	// 2 branches to 3 and 4, both reach 4
+	g := dfa.MustParseGraph("entry 1; 1 -> 2 -> 4; 2 -T-> 3 -> 4")
+
+	// visited collects the labels of the nodes on some path
+	visited := func(dropTaken bool) dfa.Analysis {
+		return dfa.Analysis{
+			Merge: maskUnion,
+			Flow: func(f dfa.Fact, n dfa.Node) (dfa.Fact, dfa.Fact) {
+				out := f.(mask) | maskOf(n.Label())
+				if dropTaken && n.Label() == 2 {
+					return out, nil
+				}
+				return out, out
+			},
+			Initial: mask(0),
+			Entry:   mask(0),
+		}
+	}
+	// length is the length of the longest path, it never takes the branch at 2
+	length := dfa.Analysis{
+		Merge: counterMax,
+		Flow: func(f dfa.Fact, n dfa.Node) (dfa.Fact, dfa.Fact) {
+			out := f.(counter) + 1
+			if n.Label() == 2 {
+				return out, nil
+			}
+			return out, out
+		},
+		Initial: counter(0),
+		Entry:   counter(0),
+	}
+	reduce := func(p dfa.ProductFact, n dfa.Node) dfa.ProductFact {
+		return dfa.ProductFact{p[0].(mask) | maskOf(reduced), p[1]}
+	}
+
+	tests := []struct {
+		name        string
+		product     dfa.Analysis
+		in          map[int]dfa.ProductFact
+		outNotTaken map[int]dfa.ProductFact
+		outTaken    map[int]dfa.ProductFact
+	}{
+		{
+			// Only length drops the taken edge of 2, so 3 is reached with its initial fact for length
+			name:    "one component drops an edge",
+			product: dfa.Product(reduce, visited(false), length),
+			in: map[int]dfa.ProductFact{
+				1: {mask(0), counter(0)},
+				2: {maskOf(1, reduced), counter(1)},
+				3: {maskOf(1, 2, reduced), counter(0)},
+				4: {maskOf(1, 2, 3, reduced), counter(2)},
+			},
+			outNotTaken: map[int]dfa.ProductFact{2: {maskOf(1, 2, reduced), counter(2)}},
+			outTaken:    map[int]dfa.ProductFact{2: {maskOf(1, 2, reduced), counter(0)}},
+		},
+		{
+			// Both components drop the taken edge of 2, so nothing flows into 3, which only contributes what it computes
+			// from the initial fact
+			name:    "all components drop an edge",
+			product: dfa.Product(reduce, visited(true), length),
+			in: map[int]dfa.ProductFact{
+				3: {mask(0), counter(0)},
+				4: {maskOf(1, 2, 3, reduced), counter(2)},
+			},
+			outNotTaken: map[int]dfa.ProductFact{2: {maskOf(1, 2, reduced), counter(2)}},
+			outTaken:    map[int]dfa.ProductFact{2: {mask(0), counter(0)}},
+		},
+		{
+			name:    "no reduction",
+			product: dfa.Product(nil, visited(false), length),
+			in: map[int]dfa.ProductFact{
+				3: {maskOf(1, 2), counter(0)},
+				4: {maskOf(1, 2, 3), counter(2)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, outNotTaken, outTaken := tt.product.Run(g.Entries, g.IDs(), g.Nodes())
+			check := func(name string, got map[int]dfa.Fact, want map[int]dfa.ProductFact) {
+				for id, w := range want {
+					if !got[id].Equals(w) {
+						t.Errorf("%s(%d) = %v, want %v", name, id, got[id], w)
+					}
+				}
+			}
+			check("in", in, tt.in)
+			check("outNotTaken", outNotTaken, tt.outNotTaken)
+			check("outTaken", outTaken, tt.outTaken)
+		})
+	}
+}
+
+func TestProductFlowDropsEdge(t *testing.T) {
+	// The flow function of the product returns nil only if every component does
+	g := dfa.MustParseGraph("entry 1; 1 -> 2; 1 -T-> 3")
+	drop := func(taken bool) dfa.Analysis {
+		return dfa.Analysis{
+			Merge: maskUnion,
+			Flow: func(f dfa.Fact, n dfa.Node) (dfa.Fact, dfa.Fact) {
+				if taken {
+					return f, nil
+				}
+				return nil, f
+			},
+			Initial: mask(0),
+			Entry:   maskOf(0),
+		}
+	}
+	n := g.Nodes()[1]
+
+	notTaken, taken := dfa.Product(nil, drop(true), drop(true)).Flow(dfa.ProductFact{maskOf(0), maskOf(0)}, n)
+	if notTaken == nil || taken != nil {
+		t.Errorf("both components drop the taken edge: got %v, %v", notTaken, taken)
+	}
+	notTaken, taken = dfa.Product(nil, drop(true), drop(false)).Flow(dfa.ProductFact{maskOf(0), maskOf(0)}, n)
+	want := dfa.ProductFact{maskOf(0), mask(0)}
+	if notTaken == nil || !notTaken.Equals(want) || taken == nil {
+		t.Errorf("one component drops each edge: got %v, %v, want %v on the not-taken edge", notTaken, taken, want)
+	}
+}
@@ -0,0 +1,116 @@
+package dataflowanalysis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Provenance records, for the final in fact of every node, which predecessors contributed to it and in which step
+// it last changed. Pass an empty Provenance to a solver using WithProvenance, then query it with Explain.
+//
+// For backward analyses the roles are swapped: the recorded facts are the out facts, merged from the successors.
+type Provenance struct {
+	Origins map[int]*Origin // The origin of the final in fact of each evaluated node
+
+	idToNode map[int]Node
+	facts    map[int]Fact
+	backward bool
+	pending  map[int]*Origin // The contributions found by the last merge at each node
+}
+
+// An Origin is where the in fact of a node comes from
+type Origin struct {
+	Label   int
+	Step    int            // The evaluation step, counting from 1, that last changed the in fact
+	Preds   []Contribution // The predecessor edges whose out facts were merged into the in fact
+	Entry   bool           // Whether the entry fact was merged into the in fact
+	Widened bool           // Whether a widening operator was applied to the merged fact, e.g. at a head by RunForwardWTO
+}
+
+// A Contribution is a predecessor edge whose out fact was merged into an in fact
+type Contribution struct {
+	Pred  int
+	Taken bool
+	Step  int // The evaluation step that last changed the out fact of the edge
+}
+
+// An Explanation is a link of the chain returned by Explain
+type Explanation struct {
+	Origin
+	Fact Fact // The final in fact of the node
+	Stmt Stmt
+
+	prov *Provenance
+}
+
+func (e Explanation) String() string {
+	fact := "in"
+	if e.prov.backward {
+		fact = "out"
+	}
+	str := fmt.Sprintf("%s[%d] = %v", fact, e.Label, e.Fact)
+	if e.Step == 0 {
+		return str + " because the node was never evaluated"
+	}
+
+	reasons := make([]string, 0, len(e.Preds)+1)
+	for _, c := range e.Preds {
+		edge := ""
+		if c.Taken {
+			edge = " taken"
+		}
+		reasons = append(reasons, fmt.Sprintf("node %d (%q)%s", c.Pred, fmt.Sprint(e.prov.idToNode[c.Pred].Get()), edge))
+	}
+	if e.Entry {
+		reasons = append(reasons, "the entry fact")
+	}
+
+	switch len(reasons) {
+	case 0:
+		str += " because no fact flows in"
+	case 1:
+		str += " because of " + reasons[0]
+	default:
+		str += " because merge of " + strings.Join(reasons[:len(reasons)-1], ", ") + " and " + reasons[len(reasons)-1]
+	}
+	if e.Widened {
+		str += ", widened"
+	}
+	return fmt.Sprintf("%s, at step %d", str, e.Step)
+}
+
+// Explain walks back from the node along the contributions that last changed each fact, returning the chain of
+// Explanations from the node up to an entry, or to a node already in the chain when going around a loop
+func (p *Provenance) Explain(id int) []Explanation {
+	chain := make([]Explanation, 0)
+	visited := make(map[int]bool)
+	for {
+		visited[id] = true
+		o, ok := p.Origins[id]
+		if !ok {
+			o = &Origin{Label: id}
+		}
+		chain = append(chain, Explanation{Origin: *o, Fact: p.facts[id], Stmt: p.idToNode[id].Get(), prov: p})
+
+		// Continue with the contribution that changed last, the one that made the fact what it is
+		next, step := 0, 0
+		for _, c := range o.Preds {
+			if c.Step > step {
+				next, step = c.Pred, c.Step
+			}
+		}
+		if step == 0 || visited[next] {
+			return chain
+		}
+		id = next
+	}
+}
+
+// start prepares the Provenance for a run over the graph, whose in facts are facts
+func (p *Provenance) start(idToNode map[int]Node, facts map[int]Fact, backward bool) {
+	p.Origins = make(map[int]*Origin, len(idToNode))
+	p.idToNode = idToNode
+	p.facts = facts
+	p.backward = backward
+	p.pending = make(map[int]*Origin)
+}
@@ -0,0 +1,77 @@
+package dataflowanalysis_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"reflect"
+	"testing"
+)
+
+// loopGraph counts i up in the loop 2 3, which 2 leaves to 4. Counting it with counter and counterWiden along the
+// WTO widens at the head 2, in the same steps every run.
+const loopGraph = `entry 1; 1 -> 2 -> 3 -> 2; 2 -T-> 4
+	1 "i = 0"; 2 "if i"; 3 "i++"; 4 "return"`
+
+func TestProvenance(t *testing.T) {
+	g := dfa.MustParseGraph(loopGraph)
+	var p dfa.Provenance
+	dfa.RunForwardWTOPI(g.Entries, g.IDs(), g.NodesPI(), counterMax, counterFlow, counter(0), counter(0), counterWiden,
+		dfa.WithProvenance(&p))
+
+	tests := []struct {
+		label  int
+		chain  []dfa.Origin
+		reason []string // The String of each link of the chain
+	}{
+		{
+			label:  1,
+			chain:  []dfa.Origin{{Label: 1, Step: 1, Preds: []dfa.Contribution{}, Entry: true}},
+			reason: []string{"in[1] = 0 because of the entry fact, at step 1"},
+		},
+		{
+			// The chain follows the contribution of 3, which changed after the one of 1, and stops when it is back at 2
+			label: 4,
+			chain: []dfa.Origin{
+				{Label: 4, Step: 6, Preds: []dfa.Contribution{{Pred: 2, Step: 4}}},
+				{Label: 2, Step: 4, Preds: []dfa.Contribution{{Pred: 1, Step: 1}, {Pred: 3, Step: 3}}, Widened: true},
+				{Label: 3, Step: 5, Preds: []dfa.Contribution{{Pred: 2, Step: 4}}},
+			},
+			reason: []string{
+				`in[4] = 1000 because of node 2 ("if i"), at step 6`,
+				`in[2] = 1000 because merge of node 1 ("i = 0") and node 3 ("i++"), widened, at step 4`,
+				`in[3] = 1000 because of node 2 ("if i"), at step 5`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		chain := p.Explain(tt.label)
+		origins := make([]dfa.Origin, len(chain))
+		reason := make([]string, len(chain))
+		for i, e := range chain {
+			origins[i], reason[i] = e.Origin, e.String()
+		}
+		if !reflect.DeepEqual(origins, tt.chain) {
+			t.Errorf("Explain(%d) = %+v, want %+v", tt.label, origins, tt.chain)
+		}
+		if !reflect.DeepEqual(reason, tt.reason) {
+			t.Errorf("Explain(%d) reads %q, want %q", tt.label, reason, tt.reason)
+		}
+	}
+}
+
+func TestProvenanceTaken(t *testing.T) {
+	// The path-sensitive origins tell the taken edge of 2 from its not-taken edge
+	g := dfa.MustParseGraph(`entry 1; 1 -> 2 -> 3; 2 -T-> 3; 2 "if p"`)
+	var p dfa.Provenance
+	dfa.RunForwardWTO(g.Entries, g.IDs(), g.Nodes(), visited.Merge, visited.Flow, visited.Initial, visited.Entry, nil,
+		dfa.WithProvenance(&p))
+
+	want := dfa.Origin{Label: 3, Step: 3, Preds: []dfa.Contribution{{Pred: 2, Step: 2}, {Pred: 2, Taken: true, Step: 2}}}
+	if got := *p.Origins[3]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Origins[3] = %+v, want %+v", got, want)
+	}
+	reason := `in[3] = 0x6 because merge of node 2 ("if p") and node 2 ("if p") taken, at step 3`
+	if got := p.Explain(3)[0].String(); got != reason {
+		t.Errorf("Explain(3) reads %q, want %q", got, reason)
+	}
+}
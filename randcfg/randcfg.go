@@ -0,0 +1,223 @@
+// Package randcfg generates random dataflowanalysis graphs for stress testing domains and solvers on shapes real
+// programs rarely produce.
+//
+// Graphs are grown from structured regions (sequences, branches, switches and loops), which are then distorted by
+// edges that enter loops sideways, unreachable regions and additional entries. The same seed and Config always
+// produce the same graph.
+package randcfg
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"math/rand"
+)
+
+// A Config controls the shape of generated graphs, zero fields take the documented defaults
+type Config struct {
+	Nodes      int     // Approximate number of reachable nodes, 20 by default
+	Branching  int     // Maximum number of successors of branch nodes, 2 (if-else) by default, more make switches
+	BranchProb float64 // Probability of a branch at each point of a region, 0.3 by default, negative for none
+	LoopDepth  int     // Maximum nesting depth of loops, 2 by default, -1 for acyclic graphs
+	LoopProb   float64 // Probability of a loop at each point of a region, 0.2 by default, negative for none
+
+	// Number of edges into loop bodies that bypass the loop head, each usually makes the graph irreducible
+	Irreducible int
+	// Number of regions unreachable from the entries, flowing into the reachable part
+	Unreachable int
+	// Number of entries, 1 by default, additional entries head regions flowing into the reachable part
+	Entries int
+
+	Stmts StmtFunc // Generates the statements, nodes have nil statements if it is nil
+}
+
+// A StmtFunc generates the statement of a node once the graph is complete, so it may inspect the node's edges,
+// e.g. to generate conditions for branches
+type StmtFunc func(r *rand.Rand, n *dfa.GraphNode) dfa.Stmt
+
+// A generator holds the state of one Generate call
+type generator struct {
+	r      *rand.Rand
+	c      Config
+	g      *dfa.Graph
+	last   int // The last label used
+	budget int // The number of nodes the current region may still add
+	loops  []loop
+}
+
+// A loop is a generated loop, whose nodes are labeled head, head+1, ..., last
+type loop struct {
+	head, last int
+}
+
+// Generate generates a graph from the seed
+func Generate(seed int64, c Config) *dfa.Graph {
+	c = c.withDefaults()
+	gen := &generator{r: rand.New(rand.NewSource(seed)), c: c, g: dfa.NewGraph()}
+
+	gen.budget = c.Nodes
+	entry, _ := gen.region(0, true)
+	gen.g.SetEntry(entry)
+	reachable := gen.last
+
+	for i := 0; i < c.Irreducible; i++ {
+		gen.enterSideways()
+	}
+
+	// Additional entries and unreachable regions are small and flow into a random reachable node outside of loop
+	// bodies, so they do not make the graph irreducible
+	targets := make([]int, 0, reachable)
+	for id := 1; id <= reachable; id++ {
+		if !gen.inLoopBody(id) {
+			targets = append(targets, id)
+		}
+	}
+	extra := c.Nodes/8 + 1
+	for i := 0; i < c.Entries-1+c.Unreachable; i++ {
+		gen.budget = extra
+		entry, exit := gen.region(0, true)
+		gen.g.AddEdge(exit, targets[gen.r.Intn(len(targets))], false)
+		if i < c.Entries-1 {
+			gen.g.SetEntry(entry)
+		}
+	}
+
+	if c.Stmts != nil {
+		for _, id := range gen.g.IDs() {
+			gen.g.SetStmt(id, c.Stmts(gen.r, gen.g.Node(id)))
+		}
+	}
+	return gen.g
+}
+
+func (c Config) withDefaults() Config {
+	if c.Nodes <= 0 {
+		c.Nodes = 20
+	}
+	if c.Branching < 2 {
+		c.Branching = 2
+	}
+	if c.BranchProb == 0 {
+		c.BranchProb = 0.3
+	} else if c.BranchProb < 0 {
+		c.BranchProb = 0
+	}
+	if c.LoopDepth == 0 {
+		c.LoopDepth = 2
+	}
+	if c.LoopProb == 0 {
+		c.LoopProb = 0.2
+	} else if c.LoopProb < 0 {
+		c.LoopProb = 0
+	}
+	if c.Entries <= 0 {
+		c.Entries = 1
+	}
+	return c
+}
+
+func (gen *generator) node() int {
+	gen.last++
+	gen.budget--
+	gen.g.AddNode(gen.last, nil)
+	return gen.last
+}
+
+// region generates a single-entry single-exit region of parts in sequence at the loop depth. The top-level region
+// continues until the budget is spent, nested ones stop at random.
+func (gen *generator) region(depth int, top bool) (entry, exit int) {
+	entry, exit = gen.part(depth)
+	for gen.budget > 0 && (top || gen.r.Float64() < 0.5) {
+		e, x := gen.part(depth)
+		gen.g.AddEdge(exit, e, false)
+		exit = x
+	}
+	return entry, exit
+}
+
+// part generates a single node, a branch or a loop
+func (gen *generator) part(depth int) (entry, exit int) {
+	if gen.budget <= 1 {
+		n := gen.node()
+		return n, n
+	}
+
+	loopProb := gen.c.LoopProb
+	if depth >= gen.c.LoopDepth {
+		loopProb = 0
+	}
+	roll := gen.r.Float64()
+	switch {
+	case roll < loopProb:
+		// The head branches into the body when taken, and out of the loop when not
+		head := gen.node()
+		bodyEntry, bodyExit := gen.region(depth+1, false)
+		gen.g.AddEdge(head, bodyEntry, true)
+		gen.g.AddEdge(bodyExit, head, false)
+		gen.loops = append(gen.loops, loop{head: head, last: gen.last})
+		exit := gen.node()
+		gen.g.AddEdge(head, exit, false)
+		return head, exit
+
+	case roll < loopProb+gen.c.BranchProb:
+		// The first arm is not taken, all others are taken, some arms are empty
+		cond := gen.node()
+		arms := 2 + gen.r.Intn(gen.c.Branching-1)
+		exits := make([]int, 0, arms)
+		emptyNotTaken, emptyTaken := false, false
+		for i := 0; i < arms; i++ {
+			if gen.budget <= 0 || gen.r.Float64() < 0.2 {
+				if i == 0 {
+					emptyNotTaken = true
+				} else {
+					emptyTaken = true
+				}
+				continue
+			}
+			e, x := gen.region(depth, false)
+			gen.g.AddEdge(cond, e, i > 0)
+			exits = append(exits, x)
+		}
+		join := gen.node()
+		for _, x := range exits {
+			gen.g.AddEdge(x, join, false)
+		}
+		if emptyNotTaken {
+			gen.g.AddEdge(cond, join, false)
+		}
+		if emptyTaken {
+			gen.g.AddEdge(cond, join, true)
+		}
+		return cond, join
+	}
+
+	n := gen.node()
+	return n, n
+}
+
+// inLoopBody returns whether the node is part of a loop, but not its head
+func (gen *generator) inLoopBody(id int) bool {
+	for _, l := range gen.loops {
+		if l.head < id && id <= l.last {
+			return true
+		}
+	}
+	return false
+}
+
+// enterSideways adds a taken edge from a node generated before a random loop to a node of its body other than the
+// head, the loop then has two entries. It does nothing if there is no such pair of nodes.
+func (gen *generator) enterSideways() {
+	candidates := make([]loop, 0, len(gen.loops))
+	for _, l := range gen.loops {
+		if l.head > 1 && l.last > l.head {
+			candidates = append(candidates, l)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	l := candidates[gen.r.Intn(len(candidates))]
+	from := 1 + gen.r.Intn(l.head-1)
+	to := l.head + 1 + gen.r.Intn(l.last-l.head)
+	gen.g.AddEdge(from, to, true)
+}
@@ -0,0 +1,107 @@
+package randcfg
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/analyses"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// An Assign is a generated statement that assigns an expression over Uses to Def, or, at branches, tests the
+// expression and assigns nothing
+type Assign struct {
+	Def  string
+	Uses []string
+}
+
+func (a *Assign) String() string {
+	expr := strings.Join(a.Uses, " + ")
+	if expr == "" {
+		expr = "0"
+	}
+	if a.Def == "" {
+		return "if " + expr
+	}
+	return a.Def + " = " + expr
+}
+
+// Assignments returns a StmtFunc generating Assigns over the variables, each using up to maxUses of them in sorted
+// order. It panics if vars is empty, since every node but branches assigns a variable.
+func Assignments(vars []string, maxUses int) StmtFunc {
+	if len(vars) == 0 {
+		panic("randcfg: Assignments needs at least one variable")
+	}
+	vars = append([]string{}, vars...)
+	return func(r *rand.Rand, n *dfa.GraphNode) dfa.Stmt {
+		a := &Assign{Uses: make([]string, 0)}
+		uses := 0
+		if maxUses > 0 {
+			uses = r.Intn(maxUses + 1)
+		}
+		for i := 0; i < uses; i++ {
+			a.Uses = appendUnique(a.Uses, vars[r.Intn(len(vars))])
+		}
+		sort.Strings(a.Uses)
+		if len(n.SuccsTaken()) == 0 {
+			a.Def = vars[r.Intn(len(vars))]
+		}
+		return a
+	}
+}
+
+// Constant returns a StmtFunc attaching the same statement to every node
+func Constant(stmt dfa.Stmt) StmtFunc {
+	return func(*rand.Rand, *dfa.GraphNode) dfa.Stmt {
+		return stmt
+	}
+}
+
+// DefUse describes the variables the Assigns of the graph define and use, and the expressions they evaluate, for
+// the analyses package. Nodes whose statements are not Assigns define and use nothing.
+func DefUse(g *dfa.Graph) analyses.ExprDefUse {
+	return assignDefUse{g: g}
+}
+
+type assignDefUse struct {
+	g *dfa.Graph
+}
+
+func (du assignDefUse) assign(label int) *Assign {
+	n := du.g.Node(label)
+	if n == nil {
+		return nil
+	}
+	a, _ := n.Get().(*Assign)
+	return a
+}
+
+func (du assignDefUse) Defs(label int) []string {
+	if a := du.assign(label); a != nil && a.Def != "" {
+		return []string{a.Def}
+	}
+	return []string{}
+}
+
+func (du assignDefUse) Uses(label int) []string {
+	if a := du.assign(label); a != nil {
+		return a.Uses
+	}
+	return []string{}
+}
+
+func (du assignDefUse) Exprs(label int) []analyses.Expr {
+	if a := du.assign(label); a != nil && len(a.Uses) > 1 {
+		return []analyses.Expr{{Text: strings.Join(a.Uses, " + "), Vars: a.Uses}}
+	}
+	return []analyses.Expr{}
+}
+
+func appendUnique(xs []string, x string) []string {
+	for _, y := range xs {
+		if y == x {
+			return xs
+		}
+	}
+	return append(xs, x)
+}
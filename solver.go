@@ -0,0 +1,287 @@
+package dataflowanalysis
+
+import (
+	"context"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// A forwardSolver holds the state of a path-sensitive forward analysis, shared by the iteration strategies
+type forwardSolver struct {
+	idToNode    map[int]Node
+	merge       func(Fact, Fact) Fact
+	flow        func(Fact, Node) (Fact, Fact)
+	initialFlow Fact
+	entryFlow   Fact
+	isEntry     map[int]bool
+	provenance  *Provenance
+	history     *History
+	stepHook    func(*StepEvent)
+	stats       *Stats
+	timing      bool
+	profile     context.Context
+	backward    bool
+	worklist    func() []int // Returns the current worklist, set by worklist-based solvers for the step hook
+
+	// The number of flow evaluations so far, and the step that last changed each out fact
+	steps        int
+	stepNotTaken map[int]int
+	stepTaken    map[int]int
+
+	// The in and out sets for each node
+	in          map[int]Fact
+	outNotTaken map[int]Fact
+	outTaken    map[int]Fact
+}
+
+func newForwardSolver(
+	entryIds []int,
+	ids []int,
+	idToNode map[int]Node,
+	merge func(Fact, Fact) Fact,
+	flow func(Fact, Node) (Fact, Fact),
+	initialFlow Fact,
+	entryFlow Fact,
+	cfg *config,
+) *forwardSolver {
+	// The number of nodes we are working with
+	n := len(ids)
+
+	s := &forwardSolver{
+		idToNode:     idToNode,
+		merge:        merge,
+		flow:         flow,
+		initialFlow:  initialFlow,
+		entryFlow:    entryFlow,
+		isEntry:      make(map[int]bool),
+		provenance:   cfg.provenance,
+		history:      cfg.history,
+		stepHook:     cfg.stepHook,
+		stats:        cfg.stats,
+		timing:       cfg.stats != nil && cfg.timing,
+		profile:      cfg.profile,
+		backward:     cfg.backward,
+		stepNotTaken: make(map[int]int, n),
+		stepTaken:    make(map[int]int, n),
+		in:           make(map[int]Fact, n),
+		outNotTaken:  make(map[int]Fact, n),
+		outTaken:     make(map[int]Fact, n),
+	}
+
+	for _, id := range ids {
+		s.in[id] = initialFlow
+		s.outNotTaken[id] = initialFlow
+		s.outTaken[id] = initialFlow
+	}
+
+	for _, id := range entryIds {
+		s.in[id] = entryFlow
+		s.isEntry[id] = true
+	}
+
+	if s.provenance != nil {
+		s.provenance.start(idToNode, s.in, cfg.backward)
+	}
+	if s.history != nil {
+		s.history.start(s.in, s.outNotTaken, s.outTaken, cfg.backward)
+	}
+
+	return s
+}
+
+// mergeIn merges the facts currently flowing into the node
+func (s *forwardSolver) mergeIn(id int) Fact {
+	currNode := s.idToNode[id]
+
+	inFacts := make([]Fact, 0, len(currNode.PredsNotTaken())+len(currNode.PredsTaken())+1)
+	for _, pred := range currNode.PredsNotTaken() {
+		inFacts = append(inFacts, s.outNotTaken[pred])
+	}
+	for _, pred := range currNode.PredsTaken() {
+		inFacts = append(inFacts, s.outTaken[pred])
+	}
+
+	if s.isEntry[id] {
+		inFacts = append(inFacts, s.entryFlow)
+	}
+
+	if s.provenance != nil {
+		s.recordMerge(id)
+	}
+
+	var fact Fact
+	var elapsed time.Duration
+	if !s.timing && s.profile == nil {
+		fact = mergeAll(s.merge, inFacts, s.initialFlow)
+	} else {
+		elapsed = s.call(id, "merge", func() {
+			fact = mergeAll(s.merge, inFacts, s.initialFlow)
+		})
+	}
+	if s.stats != nil && len(inFacts) > 1 {
+		ns := s.stats.node(id)
+		s.stats.Merges += len(inFacts) - 1
+		ns.Merges += len(inFacts) - 1
+		s.stats.MergeTime += elapsed
+		ns.MergeTime += elapsed
+	}
+	return fact
+}
+
+// apply records inFact as the node's in fact and flows it through the node, returning which out facts changed
+func (s *forwardSolver) apply(id int, inFact Fact) (changedNotTaken, changedTaken bool) {
+	s.steps++
+	if s.provenance != nil {
+		if _, ok := s.provenance.Origins[id]; !ok || !inFact.Equals(s.in[id]) {
+			if s.provenance.pending[id] == nil {
+				// The caller did not merge the in fact with mergeIn
+				s.recordMerge(id)
+			}
+			o := s.provenance.pending[id]
+			o.Step = s.steps
+			s.provenance.Origins[id] = o
+		}
+	}
+
+	prevIn := s.in[id]
+	s.in[id] = inFact
+	var outNotTakenFact, outTakenFact Fact
+	var elapsed time.Duration
+	if !s.timing && s.profile == nil {
+		outNotTakenFact, outTakenFact = s.flow(inFact, s.idToNode[id])
+	} else {
+		elapsed = s.call(id, "flow", func() {
+			outNotTakenFact, outTakenFact = s.flow(inFact, s.idToNode[id])
+		})
+	}
+	if s.stats != nil {
+		ns := s.stats.node(id)
+		s.stats.FlowEvals++
+		ns.Visits++
+		s.stats.FlowTime += elapsed
+		ns.FlowTime += elapsed
+	}
+
+	if outNotTakenFact != nil && !outNotTakenFact.Equals(s.outNotTaken[id]) {
+		s.outNotTaken[id] = outNotTakenFact
+		s.stepNotTaken[id] = s.steps
+		changedNotTaken = true
+	}
+
+	if outTakenFact != nil && !outTakenFact.Equals(s.outTaken[id]) {
+		s.outTaken[id] = outTakenFact
+		s.stepTaken[id] = s.steps
+		changedTaken = true
+	}
+
+	if s.stats != nil {
+		if changedNotTaken {
+			s.stats.FactChanges++
+		}
+		if changedTaken {
+			s.stats.FactChanges++
+		}
+	}
+	if s.history != nil {
+		s.history.record(s.steps, id, inFact, s.outNotTaken[id], s.outTaken[id])
+	}
+	if s.stepHook != nil {
+		s.notify(id, inFact, !inFact.Equals(prevIn), changedNotTaken, changedTaken)
+	}
+
+	return changedNotTaken, changedTaken
+}
+
+// call calls the user callback f for the node, under pprof labels if requested, and returns the time it took if
+// the callbacks are timed
+func (s *forwardSolver) call(id int, callback string, f func()) time.Duration {
+	var start time.Time
+	if s.timing {
+		start = time.Now()
+	}
+	if s.profile != nil {
+		labels := pprof.Labels("dfa.node", strconv.Itoa(id), "dfa.callback", callback)
+		pprof.Do(s.profile, labels, func(context.Context) {
+			f()
+		})
+	} else {
+		f()
+	}
+	if s.timing {
+		return time.Since(start)
+	}
+	return 0
+}
+
+// widened records that the in fact about to be applied to the node was widened after mergeIn merged it
+func (s *forwardSolver) widened(id int) {
+	if s.provenance != nil {
+		if s.provenance.pending[id] == nil {
+			s.recordMerge(id)
+		}
+		s.provenance.pending[id].Widened = true
+	}
+}
+
+// recordMerge records which facts mergeIn merged for the node, predecessors whose out facts were never produced by
+// the flow function do not contribute
+func (s *forwardSolver) recordMerge(id int) {
+	currNode := s.idToNode[id]
+	o := &Origin{Label: id, Preds: make([]Contribution, 0), Entry: s.isEntry[id]}
+	for _, pred := range currNode.PredsNotTaken() {
+		if step := s.stepNotTaken[pred]; step > 0 {
+			o.Preds = append(o.Preds, Contribution{Pred: pred, Taken: false, Step: step})
+		}
+	}
+	for _, pred := range currNode.PredsTaken() {
+		if step := s.stepTaken[pred]; step > 0 {
+			o.Preds = append(o.Preds, Contribution{Pred: pred, Taken: true, Step: step})
+		}
+	}
+	s.provenance.pending[id] = o
+}
+
+// notify calls the step hook with the step just taken
+func (s *forwardSolver) notify(id int, inFact Fact, changedIn, changedNotTaken, changedTaken bool) {
+	e := &StepEvent{
+		Step:            s.steps,
+		Label:           id,
+		Stmt:            s.idToNode[id].Get(),
+		In:              inFact,
+		OutNotTaken:     s.outNotTaken[id],
+		OutTaken:        s.outTaken[id],
+		ChangedIn:       changedIn,
+		ChangedNotTaken: changedNotTaken,
+		ChangedTaken:    changedTaken,
+	}
+	if s.backward {
+		// The reversed graph's in fact is the out fact and its out fact the in fact
+		e.In, e.OutNotTaken, e.OutTaken = s.outNotTaken[id], inFact, nil
+		e.ChangedIn, e.ChangedNotTaken, e.ChangedTaken = changedNotTaken, changedIn, false
+	}
+	if s.worklist != nil {
+		// The solver adds the successors along changed edges after the step
+		e.Worklist = s.worklist()
+		if changedNotTaken {
+			e.Worklist = append(e.Worklist, s.idToNode[id].SuccsNotTaken()...)
+		}
+		if changedTaken {
+			e.Worklist = append(e.Worklist, s.idToNode[id].SuccsTaken()...)
+		}
+		e.Worklist = sortedUnique(e.Worklist)
+	}
+	s.stepHook(e)
+}
+
+func sortedUnique(xs []int) []int {
+	sort.Ints(xs)
+	res := xs[:0]
+	for _, x := range xs {
+		if len(res) == 0 || x != res[len(res)-1] {
+			res = append(res, x)
+		}
+	}
+	return res
+}
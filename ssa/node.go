@@ -0,0 +1,53 @@
+package ssa
+
+import dfa "github.com/skius/dataflowanalysis"
+
+// A Node is a node of the SSA view, it implements both dfa.Node and dfa.NodePI and its Get returns a *Stmt
+type Node struct {
+	actualNode dfa.Node
+	stmt       *Stmt
+}
+
+func (n *Node) Label() int {
+	return n.actualNode.Label()
+}
+
+func (n *Node) PredsNotTaken() []int {
+	return n.actualNode.PredsNotTaken()
+}
+
+func (n *Node) PredsTaken() []int {
+	return n.actualNode.PredsTaken()
+}
+
+func (n *Node) SuccsNotTaken() []int {
+	return n.actualNode.SuccsNotTaken()
+}
+
+func (n *Node) SuccsTaken() []int {
+	return n.actualNode.SuccsTaken()
+}
+
+func (n *Node) Preds() []int {
+	return concat(n.PredsNotTaken(), n.PredsTaken())
+}
+
+func (n *Node) Succs() []int {
+	return concat(n.SuccsNotTaken(), n.SuccsTaken())
+}
+
+func (n *Node) Get() dfa.Stmt {
+	return n.stmt
+}
+
+// Orig returns the original node
+func (n *Node) Orig() dfa.Node {
+	return n.actualNode
+}
+
+// concat returns a new slice holding the elements of a followed by those of b
+func concat(a, b []int) []int {
+	res := make([]int, 0, len(a)+len(b))
+	res = append(res, a...)
+	return append(res, b...)
+}
@@ -0,0 +1,212 @@
+package ssa
+
+import (
+	"fmt"
+	dfa "github.com/skius/dataflowanalysis"
+)
+
+// A LatticeKind is the level of a Lattice value
+type LatticeKind int
+
+const (
+	Undefined   LatticeKind = iota // No definition has been found executable yet
+	Constant                       // Always the same constant
+	Overdefined                    // May have several values
+)
+
+/*
+	The lattice of SCCP values:
+                  Undefined
+      /      /      ...    \       \
+ ...  c1     c2     ...    c3      c4 ....     (Constants)
+      \      \      ...     /       /
+                 Overdefined
+*/
+
+// A Lattice is the value of an SSA Value during SCCP
+type Lattice struct {
+	Kind  LatticeKind
+	Const interface{} // The constant if Kind is Constant, must be comparable with ==
+}
+
+// ConstantValue returns the Lattice value of the constant c
+func ConstantValue(c interface{}) Lattice {
+	return Lattice{Kind: Constant, Const: c}
+}
+
+// Meet returns the greatest lower bound of l and other
+func (l Lattice) Meet(other Lattice) Lattice {
+	if l.Kind == Undefined {
+		return other
+	}
+	if other.Kind == Undefined {
+		return l
+	}
+	if l.Kind == Constant && other.Kind == Constant && l.Const == other.Const {
+		return l
+	}
+	return Lattice{Kind: Overdefined}
+}
+
+func (l Lattice) Equals(other Lattice) bool {
+	return l.Kind == other.Kind && (l.Kind != Constant || l.Const == other.Const)
+}
+
+func (l Lattice) String() string {
+	switch l.Kind {
+	case Undefined:
+		return "<Undefined>"
+	case Constant:
+		return fmt.Sprintf("%#v", l.Const)
+	}
+	return "<Overdefined>"
+}
+
+// An Evaluator gives meaning to the statements of a graph for SCCP, it is called with the original statements
+type Evaluator interface {
+	// Eval returns the values of the variables the statement defines, given the values of the variables it uses.
+	// Variables missing from the result are Overdefined.
+	Eval(stmt dfa.Stmt, uses map[string]Lattice) map[string]Lattice
+	// Branch returns whether the not-taken and the taken successors of a branching statement may execute
+	Branch(stmt dfa.Stmt, uses map[string]Lattice) (notTaken, taken bool)
+	// Entry returns the value of the variable on entry
+	Entry(variable string) Lattice
+}
+
+// SCCPResult holds the result of SCCP
+type SCCPResult struct {
+	Values     map[Value]Lattice // The value of every version, those not defined by executable code are Undefined
+	executable map[int]bool
+	edges      map[execEdge]bool
+}
+
+type execEdge struct {
+	from, to int
+	taken    bool
+}
+
+// Value returns the value of the version
+func (r *SCCPResult) Value(v Value) Lattice {
+	return r.Values[v]
+}
+
+// Executable returns whether the node may execute
+func (r *SCCPResult) Executable(label int) bool {
+	return r.executable[label]
+}
+
+// EdgeExecutable returns whether control may flow along the edge
+func (r *SCCPResult) EdgeExecutable(from, to int, taken bool) bool {
+	return r.edges[execEdge{from: from, to: to, taken: taken}]
+}
+
+// SCCP runs sparse conditional constant propagation (Wegman and Zadeck, "Constant Propagation with Conditional
+// Branches") on the SSA form: values are propagated along def-use edges, but only from nodes found executable, while
+// branches whose condition evaluates to a constant only make one of their successors executable.
+func SCCP(s *SSA, eval Evaluator) *SCCPResult {
+	r := &SCCPResult{
+		Values:     make(map[Value]Lattice),
+		executable: make(map[int]bool),
+		edges:      make(map[execEdge]bool),
+	}
+
+	// A single worklist of nodes, pushed when an edge into them becomes executable or a value they use changes
+	worklist := make([]int, 0)
+	queued := make(map[int]bool)
+	push := func(label int) {
+		if !queued[label] {
+			queued[label] = true
+			worklist = append(worklist, label)
+		}
+	}
+
+	for v := range s.UseSites {
+		if v.Version == 0 {
+			r.Values[v] = eval.Entry(v.Var)
+		}
+	}
+	update := func(v Value, l Lattice) {
+		// Meeting with the old value keeps the values descending even for non-monotone evaluators
+		old := r.Values[v]
+		l = old.Meet(l)
+		if l.Equals(old) {
+			return
+		}
+		r.Values[v] = l
+		for _, user := range s.UseSites[v] {
+			if r.executable[user] {
+				push(user)
+			}
+		}
+	}
+	predExecutable := func(pred, label int) bool {
+		return r.edges[execEdge{from: pred, to: label, taken: false}] || r.edges[execEdge{from: pred, to: label, taken: true}]
+	}
+	markEdge := func(from, to int, taken bool) {
+		e := execEdge{from: from, to: to, taken: taken}
+		if r.edges[e] {
+			return
+		}
+		r.edges[e] = true
+		r.executable[to] = true
+		push(to)
+	}
+
+	roots := make(map[int]bool)
+	for _, id := range s.Dom.Roots() {
+		roots[id] = true
+		r.executable[id] = true
+		push(id)
+	}
+
+	for len(worklist) > 0 {
+		label := worklist[0]
+		worklist = worklist[1:]
+		queued[label] = false
+
+		stmt := s.Stmt(label)
+		for _, p := range stmt.Phis {
+			l := Lattice{Kind: Undefined}
+			for pred, arg := range p.Args {
+				if (pred == EntryPred && roots[label]) || predExecutable(pred, label) {
+					l = l.Meet(r.Values[arg])
+				}
+			}
+			update(p.Dest, l)
+		}
+
+		uses := make(map[string]Lattice, len(stmt.Uses))
+		for _, u := range stmt.Uses {
+			uses[u.Var] = r.Values[u]
+		}
+
+		if len(stmt.Defs) > 0 {
+			results := eval.Eval(stmt.Orig, uses)
+			for _, d := range stmt.Defs {
+				res, ok := results[d.Var]
+				if !ok {
+					res = Lattice{Kind: Overdefined}
+				}
+				update(d, res)
+			}
+		}
+
+		n := s.Nodes[label]
+		notTaken, taken := true, false
+		if len(n.SuccsTaken()) > 0 {
+			notTaken, taken = eval.Branch(stmt.Orig, uses)
+		}
+		if notTaken {
+			for _, succ := range n.SuccsNotTaken() {
+				markEdge(label, succ, false)
+			}
+		}
+		if taken {
+			for _, succ := range n.SuccsTaken() {
+				markEdge(label, succ, true)
+			}
+		}
+	}
+
+	return r
+}
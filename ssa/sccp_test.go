@@ -0,0 +1,154 @@
+package ssa_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/ssa"
+	"strconv"
+	"testing"
+)
+
+// evaluator implements ssa.Evaluator for the tiny language, variables are Overdefined on entry
+type evaluator struct{}
+
+func (evaluator) Eval(stmt dfa.Stmt, uses map[string]ssa.Lattice) map[string]ssa.Lattice {
+	def, rhs := parseStmt(stmt)
+	sum := 0
+	for _, tok := range rhs {
+		switch {
+		case tok == "+":
+		case tok == "?":
+			return map[string]ssa.Lattice{def: {Kind: ssa.Overdefined}}
+		case isVar(tok):
+			l := uses[tok]
+			if l.Kind != ssa.Constant {
+				return map[string]ssa.Lattice{def: l}
+			}
+			sum += l.Const.(int)
+		default:
+			n, _ := strconv.Atoi(tok)
+			sum += n
+		}
+	}
+	return map[string]ssa.Lattice{def: ssa.ConstantValue(sum)}
+}
+
+func (evaluator) Branch(stmt dfa.Stmt, uses map[string]ssa.Lattice) (notTaken, taken bool) {
+	_, rhs := parseStmt(stmt)
+	l := uses[rhs[0]]
+	switch l.Kind {
+	case ssa.Undefined:
+		return false, false
+	case ssa.Constant:
+		n, _ := strconv.Atoi(rhs[2])
+		return l.Const != n, l.Const == n
+	}
+	return true, true
+}
+
+func (evaluator) Entry(string) ssa.Lattice {
+	return ssa.Lattice{Kind: ssa.Overdefined}
+}
+
+type edge struct {
+	from, to int
+	taken    bool
+}
+
+func TestSCCP(t *testing.T) {
+	overdefined := ssa.Lattice{Kind: ssa.Overdefined}
+	tests := []struct {
+		name     string
+		graph    string
+		dead     []int // The nodes that never execute, all others do
+		deadEdge []edge
+		liveEdge []edge
+		values   map[ssa.Value]ssa.Lattice
+	}{
+		{
+			// x is 1, so the branch is always taken and only y_1 reaches the phi
+			name: "constant branch",
+			graph: `entry 1; 1 -> 2; 2 -T-> 3 -> 5; 2 -> 4 -> 5
+				1 "x = 1"; 2 "x == 1"; 3 "y = 2"; 4 "y = 3"; 5 "z = y + 1"`,
+			dead:     []int{4},
+			deadEdge: []edge{{2, 4, false}, {4, 5, false}},
+			liveEdge: []edge{{1, 2, false}, {2, 3, true}, {3, 5, false}},
+			values: map[ssa.Value]ssa.Lattice{
+				{Var: "x", Version: 1}: ssa.ConstantValue(1),
+				{Var: "y", Version: 1}: ssa.ConstantValue(2),
+				{Var: "y", Version: 2}: {Kind: ssa.Undefined},
+				{Var: "y", Version: 3}: ssa.ConstantValue(2),
+				{Var: "z", Version: 1}: ssa.ConstantValue(3),
+			},
+		},
+		{
+			// k stays constant around the loop, i does not, and n is unknown on entry
+			name: "loop",
+			graph: `entry 1; 1 -> 2 -> 3 -> 4 -> 2; 2 -T-> 5
+				1 "k = 1"; 2 "n == 0"; 3 "k = k + 0"; 4 "i = i + 1"; 5 "print k"`,
+			liveEdge: []edge{{2, 3, false}, {2, 5, true}, {4, 2, false}},
+			values: map[ssa.Value]ssa.Lattice{
+				{Var: "k", Version: 1}: ssa.ConstantValue(1),
+				{Var: "k", Version: 2}: ssa.ConstantValue(1),
+				{Var: "k", Version: 3}: ssa.ConstantValue(1),
+				{Var: "i", Version: 0}: overdefined,
+				{Var: "i", Version: 1}: overdefined,
+				{Var: "i", Version: 2}: overdefined,
+			},
+		},
+		{
+			// x is 0 around the loop, which therefore never exits
+			name: "infinite loop",
+			graph: `entry 1; 1 -> 2; 2 -T-> 3 -> 2; 2 -> 4
+				1 "x = 0"; 2 "x == 0"; 3 "x = x + 0"; 4 "print x"`,
+			dead:     []int{4},
+			deadEdge: []edge{{2, 4, false}},
+			liveEdge: []edge{{2, 3, true}, {3, 2, false}},
+			values: map[ssa.Value]ssa.Lattice{
+				{Var: "x", Version: 2}: ssa.ConstantValue(0),
+				{Var: "x", Version: 3}: ssa.ConstantValue(0),
+			},
+		},
+		{
+			// Nodes unreachable from the entry never execute
+			name: "unreachable node",
+			graph: `entry 1; 1 -> 2; 3 -> 2
+				1 "x = 1"; 2 "print x"; 3 "x = 2"`,
+			dead:     []int{3},
+			deadEdge: []edge{{3, 2, false}},
+			liveEdge: []edge{{1, 2, false}},
+			values:   map[ssa.Value]ssa.Lattice{{Var: "x", Version: 1}: ssa.ConstantValue(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := build(tt.graph)
+			res := ssa.SCCP(s, evaluator{})
+
+			dead := make(map[int]bool)
+			for _, id := range tt.dead {
+				dead[id] = true
+			}
+			for _, id := range s.IDs {
+				if res.Executable(id) == dead[id] {
+					t.Errorf("Executable(%d) = %v", id, res.Executable(id))
+				}
+			}
+			for _, e := range tt.deadEdge {
+				if res.EdgeExecutable(e.from, e.to, e.taken) {
+					t.Errorf("edge %v is executable", e)
+				}
+			}
+			for _, e := range tt.liveEdge {
+				if !res.EdgeExecutable(e.from, e.to, e.taken) {
+					t.Errorf("edge %v is not executable", e)
+				}
+			}
+			for v, want := range tt.values {
+				if got := res.Value(v); !got.Equals(want) {
+					t.Errorf("%v = %v, want %v", v, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Package ssa converts dataflowanalysis graphs into static single assignment form.
+//
+// The SSA form is a view of the original graph: it has the same labels and edges, but the statement of every node
+// is replaced by a *Stmt listing its phi functions and the versions of the variables it defines and uses, so the
+// solvers of the dataflowanalysis package run on it unchanged.
+package ssa
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/analyses"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EntryPred is the pseudo-label of the predecessor through which the entry versions flow into the phis of an entry
+const EntryPred = analyses.Uninitialized
+
+// A Value is a version of a variable, version 0 is the value the variable has on entry
+type Value struct {
+	Var     string
+	Version int
+}
+
+func (v Value) String() string {
+	return v.Var + "_" + strconv.Itoa(v.Version)
+}
+
+// A Phi selects the version of Var flowing in from the predecessor control came from
+type Phi struct {
+	Dest Value
+	Args map[int]Value // The version flowing in from each predecessor, by label
+}
+
+func (p *Phi) String() string {
+	preds := make([]int, 0, len(p.Args))
+	for pred := range p.Args {
+		preds = append(preds, pred)
+	}
+	sort.Ints(preds)
+
+	args := make([]string, len(preds))
+	for i, pred := range preds {
+		from := strconv.Itoa(pred)
+		if pred == EntryPred {
+			from = "entry"
+		}
+		args[i] = from + ": " + p.Args[pred].String()
+	}
+	return p.Dest.String() + " = phi(" + strings.Join(args, ", ") + ")"
+}
+
+// A Stmt is the SSA form of a node's statement
+type Stmt struct {
+	Phis []*Phi   // The phi functions at the start of the node, sorted by variable
+	Defs []Value  // The versions the node assigns
+	Uses []Value  // The versions the node reads
+	Orig dfa.Stmt // The statement of the original node
+}
+
+// Use returns the version of the variable the node reads, ok is false if it does not read it
+func (s *Stmt) Use(variable string) (v Value, ok bool) {
+	for _, u := range s.Uses {
+		if u.Var == variable {
+			return u, true
+		}
+	}
+	return Value{}, false
+}
+
+func (s *Stmt) String() string {
+	parts := make([]string, 0, len(s.Phis)+1)
+	for _, p := range s.Phis {
+		parts = append(parts, p.String())
+	}
+
+	strs := func(vs []Value) string {
+		res := make([]string, len(vs))
+		for i, v := range vs {
+			res[i] = v.String()
+		}
+		return strings.Join(res, ", ")
+	}
+	parts = append(parts, "["+strs(s.Defs)+"] <- ["+strs(s.Uses)+"]")
+	return strings.Join(parts, "; ")
+}
+
+// SSA is the static single assignment form of a graph
+type SSA struct {
+	IDs      []int              // The labels of all nodes, as passed to Build
+	Nodes    map[int]dfa.Node   // The SSA view of each node, ready for dfa.RunForward
+	NodesPI  map[int]dfa.NodePI // The path-insensitive SSA view of each node
+	DefSites map[Value]int      // The label of the node defining each version, by a phi or its statement
+	UseSites map[Value][]int    // The labels of the nodes reading each version, by a phi or its statement
+	Dom      *dfa.DomTree       // The dominator tree the form was built from
+	stmts    map[int]*Stmt
+}
+
+// Stmt returns the SSA statement of the node
+func (s *SSA) Stmt(label int) *Stmt {
+	return s.stmts[label]
+}
+
+// Build converts the graph into pruned SSA form: phi functions are placed at the iterated dominance frontiers of
+// each variable's definitions, where the variable is live, and variables are renamed in a walk of the dominator tree.
+// Nodes unreachable from the entries get a Stmt without versions.
+func Build(entryIds []int, ids []int, idToNode map[int]dfa.Node, du analyses.DefUse) *SSA {
+	dom := dfa.Dominators(entryIds, ids, idToNode)
+
+	live := analyses.LiveVariables(ids, dfa.PathInsensitive(idToNode), du)
+
+	s := &SSA{
+		IDs:      ids,
+		Nodes:    make(map[int]dfa.Node, len(ids)),
+		NodesPI:  make(map[int]dfa.NodePI, len(ids)),
+		DefSites: make(map[Value]int),
+		UseSites: make(map[Value][]int),
+		Dom:      dom,
+		stmts:    make(map[int]*Stmt, len(ids)),
+	}
+	for _, id := range ids {
+		s.stmts[id] = &Stmt{Orig: idToNode[id].Get()}
+	}
+
+	// Phi placement
+	defSites := make(map[string][]int)
+	for _, id := range ids {
+		if !dom.Reachable(id) {
+			continue
+		}
+		for _, v := range du.Defs(id) {
+			defSites[v] = append(defSites[v], id)
+		}
+	}
+	vars := make([]string, 0, len(defSites))
+	for v := range defSites {
+		vars = append(vars, v)
+	}
+	sort.Strings(vars)
+	for _, v := range vars {
+		for _, id := range dom.IteratedFrontier(defSites[v]) {
+			if live.In[id].Contains(v) {
+				s.stmts[id].Phis = append(s.stmts[id].Phis, &Phi{Dest: Value{Var: v}, Args: make(map[int]Value)})
+			}
+		}
+	}
+
+	// Renaming, stacks[v] holds the versions of v defined on the path from the root to the current node
+	counters := make(map[string]int)
+	stacks := make(map[string][]Value)
+	top := func(v string) Value {
+		if st := stacks[v]; len(st) > 0 {
+			return st[len(st)-1]
+		}
+		return Value{Var: v}
+	}
+	pushed := make(map[int][]string)
+	define := func(label int, v string) Value {
+		counters[v]++
+		val := Value{Var: v, Version: counters[v]}
+		stacks[v] = append(stacks[v], val)
+		pushed[label] = append(pushed[label], v)
+		s.DefSites[val] = label
+		return val
+	}
+	use := func(label int, val Value) {
+		s.UseSites[val] = append(s.UseSites[val], label)
+	}
+
+	for _, id := range dom.Roots() {
+		for _, p := range s.stmts[id].Phis {
+			p.Args[EntryPred] = Value{Var: p.Dest.Var}
+			use(id, p.Args[EntryPred])
+		}
+	}
+
+	dom.Visit(func(id int) {
+		stmt := s.stmts[id]
+		for _, p := range stmt.Phis {
+			p.Dest = define(id, p.Dest.Var)
+		}
+		for _, v := range du.Uses(id) {
+			val := top(v)
+			stmt.Uses = append(stmt.Uses, val)
+			use(id, val)
+		}
+		for _, v := range du.Defs(id) {
+			stmt.Defs = append(stmt.Defs, define(id, v))
+		}
+
+		n := idToNode[id]
+		for _, succ := range concat(n.SuccsNotTaken(), n.SuccsTaken()) {
+			for _, p := range s.stmts[succ].Phis {
+				if _, ok := p.Args[id]; ok {
+					continue
+				}
+				p.Args[id] = top(p.Dest.Var)
+				use(succ, p.Args[id])
+			}
+		}
+	}, func(id int) {
+		for _, v := range pushed[id] {
+			stacks[v] = stacks[v][:len(stacks[v])-1]
+		}
+	})
+
+	for _, id := range ids {
+		node := &Node{actualNode: idToNode[id], stmt: s.stmts[id]}
+		s.Nodes[id] = node
+		s.NodesPI[id] = node
+	}
+	return s
+}
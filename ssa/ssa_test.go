@@ -0,0 +1,139 @@
+package ssa_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"github.com/skius/dataflowanalysis/ssa"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+/*
+	The statements of the test graphs form a tiny language over integers:
+		x = 1 + y     An assignment of a sum of constants and variables
+		x = ?         An assignment of an unknown value
+		x == 1        A branch, taken if the variable equals the constant
+		print x       A use
+*/
+
+func isVar(tok string) bool {
+	return tok != "" && unicode.IsLetter(rune(tok[0])) && tok != "print"
+}
+
+// parseStmt returns the variable the statement assigns, or "", and the tokens of its right-hand side
+func parseStmt(stmt dfa.Stmt) (def string, rhs []string) {
+	s, _ := stmt.(string)
+	toks := strings.Fields(s)
+	if len(toks) >= 2 && toks[1] == "=" {
+		return toks[0], toks[2:]
+	}
+	return "", toks
+}
+
+// defUse implements analyses.DefUse for graphs of the tiny language
+type defUse struct {
+	g *dfa.Graph
+}
+
+func (du defUse) Defs(label int) []string {
+	if def, _ := parseStmt(du.g.Node(label).Get()); def != "" {
+		return []string{def}
+	}
+	return []string{}
+}
+
+func (du defUse) Uses(label int) []string {
+	_, rhs := parseStmt(du.g.Node(label).Get())
+	uses := make([]string, 0)
+	for _, tok := range rhs {
+		if isVar(tok) {
+			uses = append(uses, tok)
+		}
+	}
+	return uses
+}
+
+func build(text string) *ssa.SSA {
+	g := dfa.MustParseGraph(text)
+	return ssa.Build(g.Entries, g.IDs(), g.Nodes(), defUse{g})
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph string
+		stmts map[int]string // The SSA statement of each node
+	}{
+		{
+			name: "diamond",
+			graph: `entry 1; 1 -> 2; 2 -T-> 3 -> 5; 2 -> 4 -> 5
+				1 "x = ?"; 2 "x == 1"; 3 "y = 2"; 4 "y = x"; 5 "print y"`,
+			stmts: map[int]string{
+				1: "[x_1] <- []",
+				2: "[] <- [x_1]",
+				3: "[y_1] <- []",
+				4: "[y_2] <- [x_1]",
+				5: "y_3 = phi(3: y_1, 4: y_2); [] <- [y_3]",
+			},
+		},
+		{
+			// t is assigned in the loop but dead at its head, so it gets no phi there
+			name: "loop",
+			graph: `entry 1; 1 -> 2 -> 3 -> 4 -> 2; 2 -T-> 5
+				1 "i = 0"; 2 "i == 10"; 3 "t = i + 1"; 4 "i = t"; 5 "print i"`,
+			stmts: map[int]string{
+				1: "[i_1] <- []",
+				2: "i_2 = phi(1: i_1, 4: i_3); [] <- [i_2]",
+				3: "[t_1] <- [i_2]",
+				4: "[i_3] <- [t_1]",
+				5: "[] <- [i_2]",
+			},
+		},
+		{
+			// The entry is a loop head, the value on entry flows into its phi
+			name: "entry in a loop",
+			graph: `entry 1; 1 -> 2 -> 1; 1 -T-> 3
+				1 "x == 0"; 2 "x = x + 1"; 3 "print x"`,
+			stmts: map[int]string{
+				1: "x_1 = phi(entry: x_0, 2: x_2); [] <- [x_1]",
+				2: "[x_2] <- [x_1]",
+				3: "[] <- [x_1]",
+			},
+		},
+		{
+			// Unreachable nodes get no versions, and do not add phi arguments
+			name: "unreachable node",
+			graph: `entry 1; 1 -> 2 -> 3 -> 4; 2 -T-> 4; 5 -> 4
+				1 "x = 1"; 2 "x == 1"; 3 "x = 2"; 4 "print x"; 5 "x = 3"`,
+			stmts: map[int]string{
+				1: "[x_1] <- []",
+				3: "[x_2] <- []",
+				4: "x_3 = phi(2: x_1, 3: x_2); [] <- [x_3]",
+				5: "[] <- []",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := build(tt.graph)
+			for label, want := range tt.stmts {
+				if got := s.Stmt(label).String(); got != want {
+					t.Errorf("node %d: %s, want %s", label, got, want)
+				}
+			}
+
+			// Only the versions on entry are used without a definition
+			for v, label := range s.DefSites {
+				if v.Version == 0 {
+					t.Errorf("%v is defined at %d", v, label)
+				}
+			}
+			for v := range s.UseSites {
+				if _, ok := s.DefSites[v]; !ok && v.Version != 0 {
+					t.Errorf("%v is used but never defined", v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package dataflowanalysis
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Stats counts the work done by a solver run. Pass a Stats to a solver using WithStats, or WithTimedStats to also
+// measure the time spent in the flow and merge functions.
+//
+// Only the worklist solvers, RunForward and the solvers built on it, maintain MaxWorklist. RunForwardWTO and
+// RunForwardElimination on reducible graphs follow the structure of the graph instead, and runs with
+// WithIterationTable evaluate every node in every round, so they leave it at 0.
+type Stats struct {
+	FlowEvals   int                `json:"flowEvals"`       // Evaluations of the flow function
+	Merges      int                `json:"merges"`          // Calls of the merge function
+	FactChanges int                `json:"factChanges"`     // Out facts changed by a flow evaluation
+	MaxWorklist int                `json:"maxWorklist"`     // The largest size of the worklist, see below
+	FlowTime    time.Duration      `json:"flowTime"`        // Time spent in the flow function, if timed
+	MergeTime   time.Duration      `json:"mergeTime"`       // Time spent in the merge function, if timed
+	Nodes       map[int]*NodeStats `json:"nodes,omitempty"` // The work done at each node, by label
+}
+
+// NodeStats counts the work done at a node
+type NodeStats struct {
+	Visits    int           `json:"visits"`    // Evaluations of the flow function at the node
+	Merges    int           `json:"merges"`    // Calls of the merge function for the in fact of the node
+	FlowTime  time.Duration `json:"flowTime"`  // Time spent in the flow function at the node, if timed
+	MergeTime time.Duration `json:"mergeTime"` // Time spent merging the in fact of the node, if timed
+}
+
+// WithStats counts the work done by the run into st, adding to its counters. The time fields are left alone, so
+// the counters cost little more than the increments.
+func WithStats(st *Stats) Option {
+	return func(c *config) {
+		c.stats = st
+		c.timing = false
+	}
+}
+
+// WithTimedStats is like WithStats, but also adds the time spent in every call of the flow and merge functions to
+// st, which reads the clock twice per call
+func WithTimedStats(st *Stats) Option {
+	return func(c *config) {
+		c.stats = st
+		c.timing = true
+	}
+}
+
+// node returns the NodeStats of the node, adding them if they are missing
+func (st *Stats) node(id int) *NodeStats {
+	if st.Nodes == nil {
+		st.Nodes = make(map[int]*NodeStats)
+	}
+	ns, ok := st.Nodes[id]
+	if !ok {
+		ns = new(NodeStats)
+		st.Nodes[id] = ns
+	}
+	return ns
+}
+
+// Costliest returns the labels of the nodes by the time spent in their flow and merge functions, costliest first,
+// which needs a run WithTimedStats. It returns at most n labels, all if n is not positive.
+func (st *Stats) Costliest(n int) []int {
+	labels := make([]int, 0, len(st.Nodes))
+	for id := range st.Nodes {
+		labels = append(labels, id)
+	}
+	cost := func(id int) time.Duration {
+		return st.Nodes[id].FlowTime + st.Nodes[id].MergeTime
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if ci, cj := cost(labels[i]), cost(labels[j]); ci != cj {
+			return ci > cj
+		}
+		return labels[i] < labels[j]
+	})
+
+	if n > 0 && n < len(labels) {
+		labels = labels[:n]
+	}
+	return labels
+}
+
+// WithProfileLabels runs the flow and merge functions under the pprof labels "dfa.node", the label of the node, and
+// "dfa.callback", "flow" or "merge", so that CPU profiles attribute the time spent in them to nodes. The labels are
+// added to those of ctx, which the goroutine returns to after each call.
+func WithProfileLabels(ctx context.Context) Option {
+	return func(c *config) {
+		c.profile = ctx
+	}
+}
@@ -0,0 +1,87 @@
+package dataflowanalysis_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatsWTO(t *testing.T) {
+	// 2 merges its two predecessors in each of its three visits, the last one finding the loop stable
+	g := dfa.MustParseGraph(loopGraph)
+	var st dfa.Stats
+	dfa.RunForwardWTOPI(g.Entries, g.IDs(), g.NodesPI(), counterMax, counterFlow, counter(0), counter(0), counterWiden,
+		dfa.WithStats(&st))
+
+	want := dfa.Stats{
+		FlowEvals:   6,
+		Merges:      3,
+		FactChanges: 6,
+		Nodes: map[int]*dfa.NodeStats{
+			1: {Visits: 1},
+			2: {Visits: 2, Merges: 3},
+			3: {Visits: 2},
+			4: {Visits: 1},
+		},
+	}
+	if !reflect.DeepEqual(st, want) {
+		t.Errorf("Stats = %+v, want %+v", st, want)
+	}
+
+	// The counters add up over runs
+	dfa.RunForwardWTOPI(g.Entries, g.IDs(), g.NodesPI(), counterMax, counterFlow, counter(0), counter(0), counterWiden,
+		dfa.WithStats(&st))
+	if st.FlowEvals != 12 || st.Merges != 6 || st.Nodes[2].Visits != 4 {
+		t.Errorf("after two runs, Stats = %+v", st)
+	}
+}
+
+func TestStatsWorklist(t *testing.T) {
+	// The worklist starts out with every node, and never holds a node twice
+	g := dfa.MustParseGraph(loopGraph)
+	var st dfa.Stats
+	dfa.RunForward(g.Entries, g.IDs(), g.Nodes(), visited.Merge, visited.Flow, visited.Initial, visited.Entry,
+		dfa.WithStats(&st))
+
+	if st.MaxWorklist != 4 {
+		t.Errorf("MaxWorklist = %d, want 4", st.MaxWorklist)
+	}
+	visits, merges := 0, 0
+	for id, ns := range st.Nodes {
+		if ns.Visits == 0 {
+			t.Errorf("node %d counted without a visit", id)
+		}
+		if id != 2 && ns.Merges != 0 {
+			t.Errorf("node %d with a single predecessor counted %d merges", id, ns.Merges)
+		}
+		visits += ns.Visits
+		merges += ns.Merges
+	}
+	if len(st.Nodes) != 4 || st.FlowEvals != visits || st.Merges != merges {
+		t.Errorf("the totals of %+v disagree with its nodes", st)
+	}
+	if st.FlowTime != 0 || st.MergeTime != 0 {
+		t.Errorf("WithStats timed the run: %+v", st)
+	}
+}
+
+func TestTimedStats(t *testing.T) {
+	g := dfa.MustParseGraph(loopGraph)
+	slow := func(f dfa.Fact, n dfa.NodePI) dfa.Fact {
+		if n.Label() == 3 {
+			time.Sleep(time.Millisecond)
+		}
+		return counterFlow(f, n)
+	}
+	var st dfa.Stats
+	dfa.RunForwardWTOPI(g.Entries, g.IDs(), g.NodesPI(), counterMax, slow, counter(0), counter(0), counterWiden,
+		dfa.WithTimedStats(&st))
+
+	if st.Nodes[3].FlowTime < 2*time.Millisecond || st.FlowTime < st.Nodes[3].FlowTime {
+		t.Errorf("the flow of 3 took %v of %v", st.Nodes[3].FlowTime, st.FlowTime)
+	}
+	if got := st.Costliest(1); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("Costliest(1) = %v, want [3]", got)
+	}
+}
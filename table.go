@@ -0,0 +1,196 @@
+package dataflowanalysis
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+)
+
+// An IterationTable holds the facts of every node after every round of a round-robin run, the way data-flow
+// courses present chaotic iteration. Pass an empty IterationTable to a solver using WithIterationTable, then render
+// it with Markdown, CSV or LaTeX.
+type IterationTable struct {
+	Rows   []TableRow
+	Rounds int // The number of rounds, the last one confirming the fixpoint
+
+	backward bool
+}
+
+// A TableRow holds one fact of a node, before solving and after every round
+type TableRow struct {
+	Label    int
+	Equation Equation // Which fact of the node the row shows
+	Facts    []Fact   // The fact before solving, then after every round
+}
+
+// Name returns the name of the row, e.g. "in(3)"
+func (r TableRow) Name() string {
+	return r.Equation.String() + "(" + strconv.Itoa(r.Label) + ")"
+}
+
+// WithIterationTable runs the solver in round-robin mode instead of its own iteration strategy, and records the
+// facts after every round into t. Every round evaluates all nodes in the order of ids, or in reverse order for
+// backward solvers, and the solver stops after a round that changes no fact. RunForwardWTO still widens at the heads
+// of its components, from the second round on.
+func WithIterationTable(t *IterationTable) Option {
+	return func(c *config) {
+		c.table = t
+	}
+}
+
+// roundRobin solves the analysis in rounds over ids, recording every round into the table. If widen is not nil, it is
+// applied to the in fact of the heads from the second round on.
+func (s *forwardSolver) roundRobin(ids []int, t *IterationTable, widen func(Fact, Fact) Fact, heads []int) {
+	order := append([]int{}, ids...)
+	if s.backward {
+		reverseInts(order)
+	}
+	isHead := make(map[int]bool, len(heads))
+	for _, id := range heads {
+		isHead[id] = true
+	}
+	t.start(s, ids)
+
+	for changed, first := true, true; changed; first = false {
+		changed = false
+		for _, id := range order {
+			inFact := s.mergeIn(id)
+			if widen != nil && !first && isHead[id] {
+				inFact = widen(s.in[id], inFact)
+				s.widened(id)
+			}
+			inChanged := !inFact.Equals(s.in[id])
+			changedNotTaken, changedTaken := s.apply(id, inFact)
+			changed = changed || inChanged || changedNotTaken || changedTaken
+		}
+		t.record(s)
+	}
+}
+
+// start prepares the table for a run over ids, adding the facts before solving
+func (t *IterationTable) start(s *forwardSolver, ids []int) {
+	t.Rows = make([]TableRow, 0, 2*len(ids))
+	t.Rounds = 0
+	t.backward = s.backward
+	for _, id := range ids {
+		if t.backward {
+			t.Rows = append(t.Rows, TableRow{Label: id, Equation: InEquation}, TableRow{Label: id, Equation: OutEquation})
+			continue
+		}
+		t.Rows = append(t.Rows, TableRow{Label: id, Equation: InEquation})
+		if len(s.idToNode[id].SuccsTaken()) == 0 {
+			t.Rows = append(t.Rows, TableRow{Label: id, Equation: OutEquation})
+		} else {
+			t.Rows = append(t.Rows, TableRow{Label: id, Equation: OutNotTakenEquation},
+				TableRow{Label: id, Equation: OutTakenEquation})
+		}
+	}
+	t.snapshot(s)
+}
+
+// record adds the facts after a round
+func (t *IterationTable) record(s *forwardSolver) {
+	t.Rounds++
+	t.snapshot(s)
+}
+
+func (t *IterationTable) snapshot(s *forwardSolver) {
+	for i := range t.Rows {
+		r := &t.Rows[i]
+		var fact Fact
+		switch {
+		case t.backward && r.Equation == InEquation:
+			fact = s.outNotTaken[r.Label]
+		case t.backward:
+			fact = s.in[r.Label]
+		case r.Equation == InEquation:
+			fact = s.in[r.Label]
+		case r.Equation == OutTakenEquation:
+			fact = s.outTaken[r.Label]
+		default:
+			fact = s.outNotTaken[r.Label]
+		}
+		r.Facts = append(r.Facts, fact)
+	}
+}
+
+// cells returns the header and the rows of the table as text
+func (t *IterationTable) cells() (header []string, rows [][]string) {
+	header = []string{"Node"}
+	for round := 0; round <= t.Rounds; round++ {
+		header = append(header, strconv.Itoa(round))
+	}
+	rows = make([][]string, len(t.Rows))
+	for i, r := range t.Rows {
+		rows[i] = []string{r.Name()}
+		for _, f := range r.Facts {
+			rows[i] = append(rows[i], f.String())
+		}
+	}
+	return header, rows
+}
+
+// Markdown renders the table as a Markdown table, a row per fact and a column per round
+func (t *IterationTable) Markdown() string {
+	escape := strings.NewReplacer("|", `\|`, "\n", "<br>")
+	line := func(cells []string) string {
+		escaped := make([]string, len(cells))
+		for i, c := range cells {
+			escaped[i] = escape.Replace(c)
+		}
+		return "| " + strings.Join(escaped, " | ") + " |\n"
+	}
+
+	header, rows := t.cells()
+	var b strings.Builder
+	b.WriteString(line(header))
+	b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, r := range rows {
+		b.WriteString(line(r))
+	}
+	return b.String()
+}
+
+// CSV renders the table as comma-separated values, a row per fact and a column per round
+func (t *IterationTable) CSV() string {
+	header, rows := t.cells()
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	// Writing to a bytes.Buffer cannot fail
+	_ = w.Write(header)
+	_ = w.WriteAll(rows)
+	return b.String()
+}
+
+// LaTeX renders the table as a LaTeX tabular environment, a row per fact and a column per round
+func (t *IterationTable) LaTeX() string {
+	escape := strings.NewReplacer(
+		`\`, `\textbackslash{}`, "{", `\{`, "}", `\}`, "&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`,
+		"^", `\textasciicircum{}`, "~", `\textasciitilde{}`, "\n", " ",
+	)
+	line := func(cells []string) string {
+		escaped := make([]string, len(cells))
+		for i, c := range cells {
+			escaped[i] = escape.Replace(c)
+		}
+		return "  " + strings.Join(escaped, " & ") + ` \\` + "\n"
+	}
+
+	header, rows := t.cells()
+	var b strings.Builder
+	b.WriteString(`\begin{tabular}{l|` + strings.Repeat("l", len(header)-1) + "}\n")
+	b.WriteString(line(header))
+	b.WriteString("  \\hline\n")
+	for _, r := range rows {
+		b.WriteString(line(r))
+	}
+	b.WriteString(`\end{tabular}` + "\n")
+	return b.String()
+}
+
+func reverseInts(xs []int) {
+	for i, j := 0, len(xs)-1; i < j; i, j = i+1, j-1 {
+		xs[i], xs[j] = xs[j], xs[i]
+	}
+}
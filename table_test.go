@@ -0,0 +1,102 @@
+package dataflowanalysis_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"testing"
+)
+
+func TestIterationTableWTO(t *testing.T) {
+	// Round 2 widens the head 2 to the top, round 3 confirms the fixpoint
+	g := dfa.MustParseGraph(loopGraph)
+	var table dfa.IterationTable
+	dfa.RunForwardWTOPI(g.Entries, g.IDs(), g.NodesPI(), counterMax, counterFlow, counter(0), counter(0), counterWiden,
+		dfa.WithIterationTable(&table))
+
+	want := `| Node | 0 | 1 | 2 | 3 |
+| --- | --- | --- | --- | --- |
+| in(1) | 0 | 0 | 0 | 0 |
+| out(1) | 0 | 1 | 1 | 1 |
+| in(2) | 0 | 1 | 1000 | 1000 |
+| out(2) | 0 | 2 | 1000 | 1000 |
+| in(3) | 0 | 2 | 1000 | 1000 |
+| out(3) | 0 | 3 | 1000 | 1000 |
+| in(4) | 0 | 2 | 1000 | 1000 |
+| out(4) | 0 | 3 | 1000 | 1000 |
+`
+	if got := table.Markdown(); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+	if table.Rounds != 3 {
+		t.Errorf("Rounds = %d, want 3", table.Rounds)
+	}
+}
+
+func TestIterationTable(t *testing.T) {
+	// Path-sensitive rows name the out facts of branches by their edge
+	g := dfa.MustParseGraph("entry 1; 1 -> 2 -> 1; 2 -T-> 3")
+	var table dfa.IterationTable
+	dfa.RunForward(g.Entries, g.IDs(), g.Nodes(), visited.Merge, visited.Flow, visited.Initial, visited.Entry,
+		dfa.WithIterationTable(&table))
+
+	csv := `Node,0,1,2,3
+in(1),0x0,0x0,0x6,0x6
+out(1),0x0,0x2,0x6,0x6
+in(2),0x0,0x2,0x6,0x6
+outNotTaken(2),0x0,0x6,0x6,0x6
+outTaken(2),0x0,0x6,0x6,0x6
+in(3),0x0,0x6,0x6,0x6
+out(3),0x0,0xe,0xe,0xe
+`
+	if got := table.CSV(); got != csv {
+		t.Errorf("CSV() =\n%s\nwant\n%s", got, csv)
+	}
+}
+
+func TestIterationTableBackward(t *testing.T) {
+	// Backward rounds evaluate the nodes in reverse order, 1 only sees the fact of 2 from the previous round
+	g := dfa.MustParseGraph("entry 1; 1 -> 2 -> 3")
+	var table dfa.IterationTable
+	dfa.RunBackwardPI(g.IDs(), g.NodesPI(), maskUnion, visitedPI, mask(0), dfa.WithIterationTable(&table))
+
+	want := `| Node | 0 | 1 | 2 |
+| --- | --- | --- | --- |
+| in(1) | 0x0 | 0xe | 0xe |
+| out(1) | 0x0 | 0xc | 0xc |
+| in(2) | 0x0 | 0xc | 0xc |
+| out(2) | 0x0 | 0x8 | 0x8 |
+| in(3) | 0x0 | 0x8 | 0x8 |
+| out(3) | 0x0 | 0x0 | 0x0 |
+`
+	if got := table.Markdown(); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestIterationTableEscapes(t *testing.T) {
+	// The in fact of the entry starts out as the entry fact, the out fact as the initial one
+	g := dfa.MustParseGraph("entry 1; 1")
+	keep := func(f dfa.Fact, _ dfa.NodePI) dfa.Fact { return f }
+	first := func(a, _ dfa.Fact) dfa.Fact { return a }
+	var table dfa.IterationTable
+	dfa.RunForwardPI(g.Entries, g.IDs(), g.NodesPI(), first, keep, dfa.TextFact("a|b\nc"), dfa.TextFact("50% & $_"),
+		dfa.WithIterationTable(&table))
+
+	markdown := `| Node | 0 | 1 | 2 |
+| --- | --- | --- | --- |
+| in(1) | 50% & $_ | 50% & $_ | 50% & $_ |
+| out(1) | a\|b<br>c | 50% & $_ | 50% & $_ |
+`
+	if got := table.Markdown(); got != markdown {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, markdown)
+	}
+	latex := `\begin{tabular}{l|lll}
+  Node & 0 & 1 & 2 \\
+  \hline
+  in(1) & 50\% \& \$\_ & 50\% \& \$\_ & 50\% \& \$\_ \\
+  out(1) & a|b c & 50\% \& \$\_ & 50\% \& \$\_ \\
+\end{tabular}
+`
+	if got := table.LaTeX(); got != latex {
+		t.Errorf("LaTeX() =\n%s\nwant\n%s", got, latex)
+	}
+}
@@ -57,3 +57,46 @@ func (n *revToFwdWrapper) SuccsTaken() []int {
 func (n *revToFwdWrapper) Get() Stmt {
 	return n.actualNode.Get()
 }
+
+// wrapPI views a path-insensitive graph as a path-sensitive one
+func wrapPI(idToNode map[int]NodePI) map[int]Node {
+	idToNodePS := make(map[int]Node, len(idToNode))
+	for k, v := range idToNode {
+		ps := new(piToPSWrapper)
+		ps.actualNode = v
+		idToNodePS[k] = ps
+	}
+	return idToNodePS
+}
+
+// A psToPIWrapper forgets the kind of the edges of a path-sensitive node, implementing a path-insensitive interface
+type psToPIWrapper struct {
+	actualNode Node
+}
+
+func (n *psToPIWrapper) Label() int {
+	return n.actualNode.Label()
+}
+
+func (n *psToPIWrapper) Preds() []int {
+	return append(append([]int{}, n.actualNode.PredsNotTaken()...), n.actualNode.PredsTaken()...)
+}
+
+func (n *psToPIWrapper) Succs() []int {
+	return append(append([]int{}, n.actualNode.SuccsNotTaken()...), n.actualNode.SuccsTaken()...)
+}
+
+func (n *psToPIWrapper) Get() Stmt {
+	return n.actualNode.Get()
+}
+
+// PathInsensitive views a path-sensitive graph as a path-insensitive one, e.g. to run a path-insensitive analysis on it
+func PathInsensitive(idToNode map[int]Node) map[int]NodePI {
+	idToNodePI := make(map[int]NodePI, len(idToNode))
+	for k, v := range idToNode {
+		pi := new(psToPIWrapper)
+		pi.actualNode = v
+		idToNodePI[k] = pi
+	}
+	return idToNodePI
+}
@@ -0,0 +1,185 @@
+package dataflowanalysis
+
+import (
+	"fmt"
+	"sort"
+)
+
+// An Equation names one of the equations of a data-flow analysis at a node
+type Equation int
+
+const (
+	InEquation          Equation = iota // The in fact must include the merge of the facts flowing in
+	OutNotTakenEquation                 // The not-taken out fact must include the flow of the in fact
+	OutTakenEquation                    // The taken out fact must include the flow of the in fact
+	OutEquation                         // The out fact of a path-insensitive node must include the flow of its in fact
+)
+
+func (e Equation) String() string {
+	switch e {
+	case InEquation:
+		return "in"
+	case OutNotTakenEquation:
+		return "outNotTaken"
+	case OutTakenEquation:
+		return "outTaken"
+	}
+	return "out"
+}
+
+// A Violation is an equation a proposed solution does not satisfy
+type Violation struct {
+	Label    int
+	Equation Equation
+	Expected Fact // The fact the equation computes from the solution, nil if the flow function leaves it as it is
+	Recorded Fact // The fact of the solution, nil if it is missing
+}
+
+func (v Violation) String() string {
+	if v.Recorded == nil {
+		if v.Expected == nil {
+			return fmt.Sprintf("node %d: %v is missing", v.Label, v.Equation)
+		}
+		return fmt.Sprintf("node %d: %v is missing, expected at least %v", v.Label, v.Equation, v.Expected)
+	}
+	return fmt.Sprintf("node %d: %v is %v, expected at least %v", v.Label, v.Equation, v.Recorded, v.Expected)
+}
+
+// Verify checks that a proposed solution of a path-sensitive forward analysis, e.g. the result of RunForward from a
+// cache, is a post-fixpoint of its equations without solving it again: every fact must be at least as conservative
+// as the fact its equation computes from the solution, where a fact f is at least as conservative as e if
+// merge(e, f) equals f. A post-fixpoint is a sound solution, though not necessarily the least one.
+// It returns every violated equation, sorted by label, and an empty slice if the solution is valid. Like RunForward
+// computes, the solution must hold all three facts of every node, a missing fact violates its equation.
+func Verify(
+	entryIds []int,
+	ids []int,
+	idToNode map[int]Node,
+	merge func(Fact, Fact) Fact, // Meet operator
+	flow func(Fact, Node) (Fact, Fact), // Flow function
+	initialFlow Fact,
+	entryFlow Fact,
+	in, outNotTaken, outTaken map[int]Fact, // The proposed solution
+) []Violation {
+	return verify(entryIds, ids, idToNode, merge, flow, initialFlow, entryFlow, in, outNotTaken, outTaken, true)
+}
+
+// verify implements Verify, checking the taken out facts only if withTaken is set, since path-insensitive solutions
+// have none
+func verify(
+	entryIds []int,
+	ids []int,
+	idToNode map[int]Node,
+	merge func(Fact, Fact) Fact,
+	flow func(Fact, Node) (Fact, Fact),
+	initialFlow Fact,
+	entryFlow Fact,
+	in, outNotTaken, outTaken map[int]Fact,
+	withTaken bool,
+) []Violation {
+	isEntry := make(map[int]bool, len(entryIds))
+	for _, id := range entryIds {
+		isEntry[id] = true
+	}
+	factOr := func(f Fact) Fact {
+		// Missing facts are violations themselves, the equations using them assume the initial fact
+		if f == nil {
+			return initialFlow
+		}
+		return f
+	}
+
+	violations := make([]Violation, 0)
+	check := func(id int, eq Equation, expected, recorded Fact) {
+		if recorded == nil {
+			violations = append(violations, Violation{Label: id, Equation: eq, Expected: expected})
+			return
+		}
+		if expected == nil {
+			// A nil flow leaves the out fact as it is
+			return
+		}
+		if !merge(expected, recorded).Equals(recorded) {
+			violations = append(violations, Violation{Label: id, Equation: eq, Expected: expected, Recorded: recorded})
+		}
+	}
+
+	sorted := append([]int{}, ids...)
+	sort.Ints(sorted)
+	for _, id := range sorted {
+		n := idToNode[id]
+		inFacts := make([]Fact, 0, len(n.PredsNotTaken())+len(n.PredsTaken())+1)
+		for _, pred := range n.PredsNotTaken() {
+			inFacts = append(inFacts, factOr(outNotTaken[pred]))
+		}
+		for _, pred := range n.PredsTaken() {
+			inFacts = append(inFacts, factOr(outTaken[pred]))
+		}
+		if isEntry[id] {
+			inFacts = append(inFacts, entryFlow)
+		}
+		check(id, InEquation, mergeAll(merge, inFacts, initialFlow), in[id])
+
+		expectedNotTaken, expectedTaken := flow(factOr(in[id]), n)
+		check(id, OutNotTakenEquation, expectedNotTaken, outNotTaken[id])
+		if withTaken {
+			check(id, OutTakenEquation, expectedTaken, outTaken[id])
+		}
+	}
+	return violations
+}
+
+// VerifyPI checks a proposed solution of a path-insensitive forward analysis, see Verify
+func VerifyPI(
+	entryIds []int,
+	ids []int,
+	idToNode map[int]NodePI,
+	merge func(Fact, Fact) Fact, // Meet operator
+	flow func(Fact, NodePI) Fact, // Flow function
+	initialFlow Fact,
+	entryFlow Fact,
+	in, out map[int]Fact, // The proposed solution
+) []Violation {
+	flowWrapper := func(f Fact, n Node) (Fact, Fact) {
+		return flow(f, n.(*piToPSWrapper).actualNode), nil
+	}
+	violations := verify(entryIds, ids, wrapPI(idToNode), merge, flowWrapper, initialFlow, entryFlow, in, out, nil, false)
+	for i := range violations {
+		if violations[i].Equation == OutNotTakenEquation {
+			violations[i].Equation = OutEquation
+		}
+	}
+	return violations
+}
+
+// VerifyBackwardPI checks a proposed solution of a path-insensitive backward analysis, as computed by
+// RunBackwardPIFrom, see Verify. The out fact of a node must include the merge of the in facts of its successors, and
+// its in fact the flow of its out fact.
+func VerifyBackwardPI(
+	exitIds []int,
+	ids []int,
+	idToNode map[int]NodePI,
+	merge func(Fact, Fact) Fact, // Meet operator
+	flow func(Fact, NodePI) Fact, // Flow function
+	initialFlow Fact,
+	exitFlow Fact,
+	in, out map[int]Fact, // The proposed solution
+) []Violation {
+	idToNodeForward := make(map[int]Node, len(idToNode))
+	for k, v := range idToNode {
+		idToNodeForward[k] = &revToFwdWrapper{actualNode: &piToPSWrapper{actualNode: v}}
+	}
+	flowWrapper := func(f Fact, n Node) (Fact, Fact) {
+		return flow(f, n.(*revToFwdWrapper).actualNode.(*piToPSWrapper).actualNode), nil
+	}
+	violations := verify(exitIds, ids, idToNodeForward, merge, flowWrapper, initialFlow, exitFlow, out, in, nil, false)
+	for i := range violations {
+		// The equations of the reversed graph swap in and out
+		if violations[i].Equation == InEquation {
+			violations[i].Equation = OutEquation
+		} else {
+			violations[i].Equation = InEquation
+		}
+	}
+	return violations
+}
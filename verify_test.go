@@ -0,0 +1,184 @@
+package dataflowanalysis_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"reflect"
+	"testing"
+)
+
+// copyFacts returns a copy of a solution the test cases can edit
+func copyFacts(facts map[int]dfa.Fact) map[int]dfa.Fact {
+	c := make(map[int]dfa.Fact, len(facts))
+	for id, f := range facts {
+		c[id] = f
+	}
+	return c
+}
+
+// The verify tests solve visited on the loop 2 3, which 2 leaves to 4, then weaken or drop one fact of the solution
+const verifyGraph = "entry 1; 1 -> 2 -> 3 -> 2; 2 -T-> 4"
+
+func visitedPI(f dfa.Fact, n dfa.NodePI) dfa.Fact {
+	return f.(mask) | maskOf(n.Label())
+}
+
+func TestVerify(t *testing.T) {
+	g := dfa.MustParseGraph(verifyGraph)
+	in, outNotTaken, outTaken := dfa.RunForward(g.Entries, g.IDs(), g.Nodes(), visited.Merge, visited.Flow,
+		visited.Initial, visited.Entry)
+
+	tests := []struct {
+		name string
+		edit func(in, outNotTaken, outTaken map[int]dfa.Fact)
+		want []dfa.Violation
+	}{
+		{
+			name: "solution",
+			edit: func(in, outNotTaken, outTaken map[int]dfa.Fact) {},
+			want: []dfa.Violation{},
+		},
+		{
+			name: "weakened in fact",
+			edit: func(in, outNotTaken, outTaken map[int]dfa.Fact) {
+				in[3] = maskOf(1)
+			},
+			want: []dfa.Violation{
+				{Label: 3, Equation: dfa.InEquation, Expected: maskOf(1, 2, 3), Recorded: maskOf(1)},
+			},
+		},
+		{
+			name: "missing taken out fact",
+			edit: func(in, outNotTaken, outTaken map[int]dfa.Fact) {
+				delete(outTaken, 2)
+			},
+			want: []dfa.Violation{{Label: 2, Equation: dfa.OutTakenEquation, Expected: maskOf(1, 2, 3)}},
+		},
+		{
+			name: "weakened not-taken out facts",
+			edit: func(in, outNotTaken, outTaken map[int]dfa.Fact) {
+				outNotTaken[1] = mask(0)
+				outNotTaken[3] = maskOf(3)
+			},
+			want: []dfa.Violation{
+				{Label: 1, Equation: dfa.OutNotTakenEquation, Expected: maskOf(1), Recorded: mask(0)},
+				{Label: 3, Equation: dfa.OutNotTakenEquation, Expected: maskOf(1, 2, 3), Recorded: maskOf(3)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, outNotTaken, outTaken := copyFacts(in), copyFacts(outNotTaken), copyFacts(outTaken)
+			tt.edit(in, outNotTaken, outTaken)
+			got := dfa.Verify(g.Entries, g.IDs(), g.Nodes(), visited.Merge, visited.Flow, visited.Initial, visited.Entry,
+				in, outNotTaken, outTaken)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyPI(t *testing.T) {
+	g := dfa.MustParseGraph(verifyGraph)
+	in, out := dfa.RunForwardPI(g.Entries, g.IDs(), g.NodesPI(), maskUnion, visitedPI, mask(0), mask(0))
+
+	tests := []struct {
+		name string
+		edit func(in, out map[int]dfa.Fact)
+		want []dfa.Violation
+	}{
+		{
+			name: "solution",
+			edit: func(in, out map[int]dfa.Fact) {},
+			want: []dfa.Violation{},
+		},
+		{
+			// The only out equation of a path-insensitive node is OutEquation
+			name: "weakened out fact",
+			edit: func(in, out map[int]dfa.Fact) {
+				out[2] = maskOf(2)
+			},
+			want: []dfa.Violation{{Label: 2, Equation: dfa.OutEquation, Expected: maskOf(1, 2, 3), Recorded: maskOf(2)}},
+		},
+		{
+			name: "missing in fact",
+			edit: func(in, out map[int]dfa.Fact) {
+				delete(in, 4)
+			},
+			want: []dfa.Violation{{Label: 4, Equation: dfa.InEquation, Expected: maskOf(1, 2, 3)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, out := copyFacts(in), copyFacts(out)
+			tt.edit(in, out)
+			got := dfa.VerifyPI(g.Entries, g.IDs(), g.NodesPI(), maskUnion, visitedPI, mask(0), mask(0), in, out)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("VerifyPI = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyBackwardPI(t *testing.T) {
+	// Backwards, visited collects the labels of the nodes on some path from a node to the exit 4
+	g := dfa.MustParseGraph(verifyGraph)
+	exits := dfa.ExitsPI(g.IDs(), g.NodesPI())
+	in, out := dfa.RunBackwardPIFrom(exits, g.IDs(), g.NodesPI(), maskUnion, visitedPI, mask(0), mask(0))
+
+	tests := []struct {
+		name string
+		edit func(in, out map[int]dfa.Fact)
+		want []dfa.Violation
+	}{
+		{
+			name: "solution",
+			edit: func(in, out map[int]dfa.Fact) {},
+			want: []dfa.Violation{},
+		},
+		{
+			// The out fact of 3 must include the in fact of its successor 2
+			name: "weakened out fact",
+			edit: func(in, out map[int]dfa.Fact) {
+				out[3] = mask(0)
+			},
+			want: []dfa.Violation{{Label: 3, Equation: dfa.OutEquation, Expected: maskOf(2, 3, 4), Recorded: mask(0)}},
+		},
+		{
+			// The in fact of 2 must include the flow of its out fact
+			name: "weakened in fact",
+			edit: func(in, out map[int]dfa.Fact) {
+				in[2] = maskOf(2)
+			},
+			want: []dfa.Violation{{Label: 2, Equation: dfa.InEquation, Expected: maskOf(2, 3, 4), Recorded: maskOf(2)}},
+		},
+		{
+			// The exit flow reaches the out fact of the exit
+			name: "missing out fact",
+			edit: func(in, out map[int]dfa.Fact) {
+				delete(out, 4)
+			},
+			want: []dfa.Violation{{Label: 4, Equation: dfa.OutEquation, Expected: mask(0)}},
+		},
+		{
+			name: "missing in fact",
+			edit: func(in, out map[int]dfa.Fact) {
+				delete(in, 1)
+			},
+			want: []dfa.Violation{{Label: 1, Equation: dfa.InEquation, Expected: maskOf(1, 2, 3, 4)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, out := copyFacts(in), copyFacts(out)
+			tt.edit(in, out)
+			got := dfa.VerifyBackwardPI(exits, g.IDs(), g.NodesPI(), maskUnion, visitedPI, mask(0), mask(0), in, out)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("VerifyBackwardPI = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
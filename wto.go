@@ -0,0 +1,218 @@
+package dataflowanalysis
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A WTOElement is an element of a weak topological ordering: either the single node Label, or, if Component is set,
+// a component with head Label followed by Elements
+type WTOElement struct {
+	Label     int
+	Component bool
+	Elements  []WTOElement
+}
+
+func (e WTOElement) String() string {
+	if !e.Component {
+		return strconv.Itoa(e.Label)
+	}
+	return "(" + strings.TrimSpace(strconv.Itoa(e.Label)+" "+WTO(e.Elements).String()) + ")"
+}
+
+// A WTO is a weak topological ordering (Bourdoncle, "Efficient chaotic iteration strategies with widenings"):
+// a hierarchical ordering of the nodes where every edge goes forward, except for the edges into the head of an
+// enclosing component. Components correspond to loops, and their heads are the natural widening points.
+type WTO []WTOElement
+
+// String returns the parenthesized notation of the ordering, e.g. "1 2 (3 4 5) 6"
+func (w WTO) String() string {
+	strs := make([]string, len(w))
+	for i, e := range w {
+		strs[i] = e.String()
+	}
+	return strings.Join(strs, " ")
+}
+
+// Heads returns the heads of all components, outermost first
+func (w WTO) Heads() []int {
+	heads := make([]int, 0)
+	for _, e := range w {
+		if e.Component {
+			heads = append(heads, e.Label)
+			heads = append(heads, WTO(e.Elements).Heads()...)
+		}
+	}
+	return heads
+}
+
+// ComputeWTO computes a weak topological ordering of all nodes, starting from the nodes in entryIds.
+// Nodes unreachable from the entries are ordered after visiting them in the order of ids.
+func ComputeWTO(entryIds []int, ids []int, idToNode map[int]Node) WTO {
+	v := newView(ids, idToNode)
+	n := len(v.labels)
+
+	const finished = int(^uint(0) >> 1)
+	dfn := make([]int, n)
+	stack := make([]int, 0)
+	num := 0
+
+	// Partitions are built by prepending, we append and reverse them once complete instead
+	var visit func(i int, partition *[]WTOElement) int
+	component := func(i int) WTOElement {
+		partition := make([]WTOElement, 0)
+		for _, s := range v.succs[i] {
+			if dfn[s] == 0 {
+				visit(s, &partition)
+			}
+		}
+		reverseWTO(partition)
+		return WTOElement{Label: v.labels[i], Component: true, Elements: partition}
+	}
+	visit = func(i int, partition *[]WTOElement) int {
+		stack = append(stack, i)
+		num++
+		dfn[i] = num
+		head := num
+		loop := false
+
+		for _, s := range v.succs[i] {
+			var min int
+			if dfn[s] == 0 {
+				min = visit(s, partition)
+			} else {
+				min = dfn[s]
+			}
+			if min <= head {
+				head = min
+				loop = true
+			}
+		}
+
+		if head == dfn[i] {
+			dfn[i] = finished
+			element := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if loop {
+				for element != i {
+					dfn[element] = 0
+					element = stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+				}
+				*partition = append(*partition, component(i))
+			} else {
+				*partition = append(*partition, WTOElement{Label: v.labels[i]})
+			}
+		}
+		return head
+	}
+
+	wto := make([]WTOElement, 0)
+	for _, i := range append(v.indicesOf(entryIds), v.indicesOf(ids)...) {
+		if dfn[i] == 0 {
+			visit(i, &wto)
+		}
+	}
+	reverseWTO(wto)
+	return wto
+}
+
+// ComputeWTOPI computes a weak topological ordering of all nodes of the path-insensitive graph, starting from the
+// nodes in entryIds
+func ComputeWTOPI(entryIds []int, ids []int, idToNode map[int]NodePI) WTO {
+	return ComputeWTO(entryIds, ids, wrapPI(idToNode))
+}
+
+func reverseWTO(w []WTOElement) {
+	for i, j := 0, len(w)-1; i < j; i, j = i+1, j-1 {
+		w[i], w[j] = w[j], w[i]
+	}
+}
+
+// RunForwardWTO computes a path-sensitive forward data-flow analysis like RunForward, but iterates along a weak
+// topological ordering using Bourdoncle's recursive strategy: every component is stabilized, inner ones first, before
+// its successors are visited. widen(old, new) is applied to the in fact of component heads from the second iteration
+// on, it may be nil if the lattice has no infinite ascending chains. With WithIterationTable, the nodes are evaluated
+// in round-robin rounds instead, still widening at the component heads.
+func RunForwardWTO(
+	entryIds []int,
+	ids []int,
+	idToNode map[int]Node,
+	merge func(Fact, Fact) Fact, // Meet operator
+	flow func(Fact, Node) (Fact, Fact), // Flow function
+	initialFlow Fact,
+	entryFlow Fact,
+	widen func(Fact, Fact) Fact, // Widening operator, applied at component heads
+	opts ...Option,
+) (in, outNotTaken, outTaken map[int]Fact) {
+	cfg := newConfig(opts)
+	s := newForwardSolver(entryIds, ids, idToNode, merge, flow, initialFlow, entryFlow, cfg)
+	wto := ComputeWTO(entryIds, ids, idToNode)
+	if cfg.table != nil {
+		s.roundRobin(ids, cfg.table, widen, wto.Heads())
+		return s.in, s.outNotTaken, s.outTaken
+	}
+
+	// A node needs re-evaluation only if its in fact changed since it was last evaluated
+	evaluated := make(map[int]bool, len(ids))
+	visitNode := func(id int) {
+		inFact := s.mergeIn(id)
+		if evaluated[id] && inFact.Equals(s.in[id]) {
+			return
+		}
+		evaluated[id] = true
+		s.apply(id, inFact)
+	}
+
+	var iterate func(WTO)
+	iterate = func(w WTO) {
+		for _, e := range w {
+			if !e.Component {
+				visitNode(e.Label)
+				continue
+			}
+
+			head := e.Label
+			for first := true; ; first = false {
+				inFact := s.mergeIn(head)
+				if !first {
+					if widen != nil {
+						inFact = widen(s.in[head], inFact)
+						s.widened(head)
+					}
+					if inFact.Equals(s.in[head]) {
+						// The head is stable, so is the rest of the component
+						break
+					}
+				}
+				evaluated[head] = true
+				s.apply(head, inFact)
+				iterate(e.Elements)
+			}
+		}
+	}
+	iterate(wto)
+
+	return s.in, s.outNotTaken, s.outTaken
+}
+
+// RunForwardWTOPI computes a path-insensitive forward data-flow analysis along a weak topological ordering, see
+// RunForwardWTO
+func RunForwardWTOPI(
+	entryIds []int,
+	ids []int,
+	idToNode map[int]NodePI,
+	merge func(Fact, Fact) Fact, // Meet operator
+	flow func(Fact, NodePI) Fact, // Flow function
+	initialFlow Fact,
+	entryFlow Fact,
+	widen func(Fact, Fact) Fact, // Widening operator, applied at component heads
+	opts ...Option,
+) (in, out map[int]Fact) {
+	flowWrapper := func(f Fact, n Node) (Fact, Fact) {
+		return flow(f, n.(*piToPSWrapper).actualNode), nil
+	}
+
+	in, out, _ = RunForwardWTO(entryIds, ids, wrapPI(idToNode), merge, flowWrapper, initialFlow, entryFlow, widen, opts...)
+	return in, out
+}
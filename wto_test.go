@@ -0,0 +1,118 @@
+package dataflowanalysis_test
+
+import (
+	dfa "github.com/skius/dataflowanalysis"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestComputeWTO(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph string
+		wto   string
+		heads []int
+	}{
+		{name: "straight line", graph: "entry 1; 1 -> 2 -> 3", wto: "1 2 3", heads: []int{}},
+		{name: "diamond", graph: "entry 1; 1 -> 2 -> 4; 1 -T-> 3 -> 4", wto: "1 3 2 4", heads: []int{}},
+		{name: "self loop", graph: "entry 1; 1 -> 1 -> 2", wto: "(1) 2", heads: []int{1}},
+		{name: "while loop", graph: "entry 1; 1 -> 2 -> 3 -> 2; 2 -T-> 4", wto: "1 (2 3) 4", heads: []int{2}},
+		{
+			name:  "nested loops",
+			graph: "entry 1; 1 -> 2 -> 3 -> 4 -> 3; 4 -T-> 5 -> 2; 2 -T-> 6",
+			wto:   "1 (2 (3 4) 5) 6",
+			heads: []int{2, 3},
+		},
+		{
+			// The example of Bourdoncle's paper
+			name:  "bourdoncle",
+			graph: "entry 1; 1 -> 2 -> 3 -> 4 -> 5 -> 6 -> 5; 6 -T-> 7 -> 3; 7 -T-> 8; 4 -T-> 7",
+			wto:   "1 2 (3 4 (5 6) 7) 8",
+			heads: []int{3, 5},
+		},
+		{
+			// Entered at 2 and 3, the first node visited becomes the head
+			name:  "irreducible cycle",
+			graph: "entry 1; 1 -> 2 -> 3 -> 2; 1 -T-> 3; 3 -T-> 4",
+			wto:   "1 (2 3) 4",
+			heads: []int{2},
+		},
+		{
+			// Unreachable nodes come before the reachable nodes they lead to
+			name:  "unreachable loop",
+			graph: "entry 1; 1 -> 2; 3 -> 4 -> 3 -> 2",
+			wto:   "(3 4) 1 2",
+			heads: []int{3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := dfa.MustParseGraph(tt.graph)
+			w := dfa.ComputeWTO(g.Entries, g.IDs(), g.Nodes())
+			if got := w.String(); got != tt.wto {
+				t.Errorf("ComputeWTO = %s, want %s", got, tt.wto)
+			}
+			if got := w.Heads(); !reflect.DeepEqual(got, tt.heads) {
+				t.Errorf("Heads() = %v, want %v", got, tt.heads)
+			}
+		})
+	}
+}
+
+// A counter is a Fact of an analysis with infinite ascending chains, counting the nodes on the longest path
+type counter int
+
+func (c counter) Equals(f dfa.Fact) bool {
+	return c == f.(counter)
+}
+
+func (c counter) String() string {
+	return strconv.Itoa(int(c))
+}
+
+const counterTop = counter(1000)
+
+func counterMax(a, b dfa.Fact) dfa.Fact {
+	if a.(counter) > b.(counter) {
+		return a
+	}
+	return b
+}
+
+func counterFlow(f dfa.Fact, _ dfa.NodePI) dfa.Fact {
+	if f.(counter) >= counterTop {
+		return f
+	}
+	return f.(counter) + 1
+}
+
+func counterWiden(old, new dfa.Fact) dfa.Fact {
+	if new.(counter) > old.(counter) {
+		return counterTop
+	}
+	return old
+}
+
+func TestRunForwardWTOWidens(t *testing.T) {
+	// Without widening, the loop 2 3 would count forever
+	g := dfa.MustParseGraph("entry 1; 1 -> 2 -> 3 -> 2; 2 -T-> 4; 4 -> 5")
+	want := map[int]dfa.Fact{1: counter(0), 2: counterTop, 3: counterTop, 4: counterTop, 5: counterTop}
+
+	in, _ := dfa.RunForwardWTOPI(g.Entries, g.IDs(), g.NodesPI(), counterMax, counterFlow, counter(0), counter(0),
+		counterWiden)
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("in = %v, want %v", in, want)
+	}
+
+	var table dfa.IterationTable
+	in, _ = dfa.RunForwardWTOPI(g.Entries, g.IDs(), g.NodesPI(), counterMax, counterFlow, counter(0), counter(0),
+		counterWiden, dfa.WithIterationTable(&table))
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("with an iteration table, in = %v, want %v", in, want)
+	}
+	if table.Rounds == 0 {
+		t.Errorf("no rounds recorded")
+	}
+}